@@ -0,0 +1,266 @@
+// Package args parses command arguments from a declarative specification.
+package args
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a flag's value.
+type Kind int
+
+const (
+	// Bool flags take no value, e.g. --all.
+	Bool Kind = iota
+	// String flags take any value, e.g. --version v1.2.3.
+	String
+	// Int flags take an integer, e.g. --page 3.
+	Int
+)
+
+// Arg is a positional argument.
+type Arg struct {
+	Name     string
+	Optional bool
+	// Rest makes the argument consume all remaining positional arguments.
+	// It must be the last argument.
+	Rest bool
+}
+
+// Flag is a named argument given as --name or --name value.
+type Flag struct {
+	Name string
+	Kind Kind
+}
+
+// Spec describes the arguments a command accepts.
+// A nil Spec accepts any positional arguments and no flags.
+type Spec struct {
+	Args  []Arg
+	Flags []Flag
+}
+
+// Args are parsed arguments.
+type Args struct {
+	values map[string]string
+	flags  map[string]string
+	raw    []string
+}
+
+// UsageError is returned when the arguments don't match the Spec.
+type UsageError struct {
+	Msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.Msg
+}
+
+func usageErrorf(format string, a ...interface{}) error {
+	return &UsageError{Msg: fmt.Sprintf(format, a...)}
+}
+
+// Tokenize splits s into words like strings.Fields, except that text in
+// double quotes is kept together. Quotes can be escaped with a backslash.
+func Tokenize(s string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		inWord  bool
+		quoted  bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && quoted && i+1 < len(s) && s[i+1] == '"':
+			i++
+			current.WriteByte('"')
+		case c == '"':
+			quoted = !quoted
+			inWord = true
+		case !quoted && (c == ' ' || c == '\n' || c == '\t' || c == '\r'):
+			if inWord {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, usageErrorf("unterminated quote")
+	}
+	if inWord {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+func (spec *Spec) flag(name string) *Flag {
+	for i := range spec.Flags {
+		if spec.Flags[i].Name == name {
+			return &spec.Flags[i]
+		}
+	}
+	return nil
+}
+
+// Parse parses tokens, which must not include the command name.
+func (spec *Spec) Parse(tokens []string) (*Args, error) {
+	a := &Args{
+		values: make(map[string]string),
+		flags:  make(map[string]string),
+	}
+	if spec == nil {
+		a.raw = tokens
+		return a, nil
+	}
+
+	var positional []string
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok == "--" {
+			positional = append(positional, tokens[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(tok, "--") || len(tok) == 2 {
+			positional = append(positional, tok)
+			continue
+		}
+
+		name, value := tok[2:], ""
+		hasValue := false
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		f := spec.flag(name)
+		if f == nil {
+			return nil, usageErrorf("unknown flag `--%s`", name)
+		}
+		if f.Kind == Bool {
+			if hasValue {
+				return nil, usageErrorf("flag `--%s` doesn't take a value", name)
+			}
+			value = "true"
+		} else if !hasValue {
+			if i+1 == len(tokens) {
+				return nil, usageErrorf("flag `--%s` needs a value", name)
+			}
+			i++
+			value = tokens[i]
+		}
+		if err := a.setFlag(f, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.setPositional(spec, positional); err != nil {
+		return nil, err
+	}
+	a.raw = positional
+	return a, nil
+}
+
+func (a *Args) setFlag(f *Flag, value string) error {
+	if f.Kind == Int {
+		if _, err := strconv.Atoi(value); err != nil {
+			return usageErrorf("flag `--%s` needs a number, not `%s`", f.Name, value)
+		}
+	}
+	a.flags[f.Name] = value
+	return nil
+}
+
+func (a *Args) setPositional(spec *Spec, positional []string) error {
+	for i, arg := range spec.Args {
+		if i >= len(positional) {
+			if !arg.Optional {
+				return usageErrorf("missing argument `%s`", arg.Name)
+			}
+			break
+		}
+		if arg.Rest {
+			a.values[arg.Name] = strings.Join(positional[i:], " ")
+			return nil
+		}
+		a.values[arg.Name] = positional[i]
+	}
+	if len(positional) > len(spec.Args) {
+		return usageErrorf("too many arguments")
+	}
+	return nil
+}
+
+// Get returns the value of a positional argument, or an empty string if it wasn't given.
+func (a *Args) Get(name string) string {
+	return a.values[name]
+}
+
+// Has reports whether the positional argument or flag was given.
+func (a *Args) Has(name string) bool {
+	_, ok := a.values[name]
+	if !ok {
+		_, ok = a.flags[name]
+	}
+	return ok
+}
+
+// Raw returns the positional arguments as given.
+func (a *Args) Raw() []string {
+	return a.raw
+}
+
+// String returns the value of a string flag, or an empty string if it wasn't given.
+func (a *Args) String(name string) string {
+	return a.flags[name]
+}
+
+// Int returns the value of an int flag, or def if it wasn't given.
+func (a *Args) Int(name string, def int) int {
+	v, ok := a.flags[name]
+	if !ok {
+		return def
+	}
+	n, _ := strconv.Atoi(v)
+	return n
+}
+
+// Bool reports whether a bool flag was given.
+func (a *Args) Bool(name string) bool {
+	return a.flags[name] == "true"
+}
+
+// Usage returns a one line usage string for the command, e.g.
+// `.funcs <package> [--page n]`.
+func (spec *Spec) Usage(command string) string {
+	var b strings.Builder
+	b.WriteString(command)
+	if spec == nil {
+		return b.String()
+	}
+	for _, arg := range spec.Args {
+		name := arg.Name
+		if arg.Rest {
+			name += "..."
+		}
+		if arg.Optional {
+			fmt.Fprintf(&b, " [%s]", name)
+		} else {
+			fmt.Fprintf(&b, " <%s>", name)
+		}
+	}
+	for _, f := range spec.Flags {
+		switch f.Kind {
+		case Bool:
+			fmt.Fprintf(&b, " [--%s]", f.Name)
+		case Int:
+			fmt.Fprintf(&b, " [--%s n]", f.Name)
+		default:
+			fmt.Fprintf(&b, " [--%s %s]", f.Name, f.Name)
+		}
+	}
+	return b.String()
+}
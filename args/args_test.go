@@ -0,0 +1,197 @@
+package args
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+		err  string
+	}{
+		{"", nil, ""},
+		{"  ", nil, ""},
+		{"strings Builder", []string{"strings", "Builder"}, ""},
+		{" a\tb\nc\r\nd ", []string{"a", "b", "c", "d"}, ""},
+		{`say "hello world"`, []string{"say", "hello world"}, ""},
+		{`a"b c"d`, []string{"ab cd"}, ""},
+		{`""`, []string{""}, ""},
+		{`"say \"hi\""`, []string{`say "hi"`}, ""},
+		// backslashes only escape quotes inside quotes
+		{`a\"b`, nil, "unterminated quote"},
+		{`"a\b"`, []string{`a\b`}, ""},
+		{`"open`, nil, "unterminated quote"},
+	}
+	for _, tt := range tests {
+		got, err := Tokenize(tt.in)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Tokenize(%q) error = %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Tokenize(%q): %v", tt.in, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+var testSpec = &Spec{
+	Args: []Arg{
+		{Name: "package"},
+		{Name: "query", Optional: true},
+	},
+	Flags: []Flag{
+		{Name: "version", Kind: String},
+		{Name: "page", Kind: Int},
+		{Name: "all", Kind: Bool},
+	},
+}
+
+var restSpec = &Spec{
+	Args: []Arg{
+		{Name: "command"},
+		{Name: "text", Optional: true, Rest: true},
+	},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec   *Spec
+		tokens string
+		// values are the positional arguments and flags given, as name=value
+		values []string
+		raw    string
+	}{
+		{testSpec, "strings", []string{"package=strings"}, "strings"},
+		{testSpec, "strings Builder", []string{"package=strings", "query=Builder"}, "strings Builder"},
+		{testSpec, "--version v1.2.3 strings", []string{"package=strings", "version=v1.2.3"}, "strings"},
+		{testSpec, "strings --version=v1.2.3", []string{"package=strings", "version=v1.2.3"}, "strings"},
+		{testSpec, "strings --version=", []string{"package=strings", "version="}, "strings"},
+		{testSpec, "strings --page 3 --all", []string{"package=strings", "page=3", "all=true"}, "strings"},
+		// everything after -- is positional
+		{testSpec, "strings -- --all", []string{"package=strings", "query=--all"}, "strings --all"},
+		// - and -- alone aren't flags
+		{testSpec, "- x", []string{"package=-", "query=x"}, "- x"},
+		{restSpec, "echo", []string{"command=echo"}, "echo"},
+		{restSpec, "echo hello big world", []string{"command=echo", "text=hello big world"}, "echo hello big world"},
+		{nil, "any --thing at all", nil, "any --thing at all"},
+	}
+	for _, tt := range tests {
+		a, err := tt.spec.Parse(strings.Fields(tt.tokens))
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.tokens, err)
+			continue
+		}
+		var values []string
+		for name, v := range a.values {
+			values = append(values, name+"="+v)
+		}
+		for name, v := range a.flags {
+			values = append(values, name+"="+v)
+		}
+		if !sameSet(values, tt.values) {
+			t.Errorf("Parse(%q) = %q, want %q", tt.tokens, values, tt.values)
+		}
+		if got := strings.Join(a.Raw(), " "); got != tt.raw {
+			t.Errorf("Parse(%q).Raw() = %q, want %q", tt.tokens, got, tt.raw)
+		}
+	}
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, s := range a {
+		seen[s] = true
+	}
+	for _, s := range b {
+		if !seen[s] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec   *Spec
+		tokens string
+		want   string
+	}{
+		{testSpec, "", "missing argument `package`"},
+		{testSpec, "--all", "missing argument `package`"},
+		{testSpec, "a b c", "too many arguments"},
+		{testSpec, "strings --nope", "unknown flag `--nope`"},
+		{testSpec, "strings --version", "flag `--version` needs a value"},
+		{testSpec, "strings --page", "flag `--page` needs a value"},
+		{testSpec, "strings --page three", "flag `--page` needs a number, not `three`"},
+		{testSpec, "strings --page=3.5", "flag `--page` needs a number, not `3.5`"},
+		{testSpec, "strings --all=yes", "flag `--all` doesn't take a value"},
+		{restSpec, "", "missing argument `command`"},
+		{&Spec{}, "extra", "too many arguments"},
+	}
+	for _, tt := range tests {
+		_, err := tt.spec.Parse(strings.Fields(tt.tokens))
+		var usage *UsageError
+		if !errors.As(err, &usage) || usage.Msg != tt.want {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.tokens, err, tt.want)
+		}
+	}
+}
+
+func TestArgsAccessors(t *testing.T) {
+	a, err := testSpec.Parse([]string{"strings", "--page", "3", "--all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Get("package"); got != "strings" {
+		t.Errorf("Get(package) = %q", got)
+	}
+	if got := a.Get("query"); got != "" {
+		t.Errorf("Get(query) = %q", got)
+	}
+	for name, want := range map[string]bool{"package": true, "query": false, "page": true, "all": true, "version": false} {
+		if got := a.Has(name); got != want {
+			t.Errorf("Has(%s) = %v, want %v", name, got, want)
+		}
+	}
+	if got := a.Int("page", 1); got != 3 {
+		t.Errorf("Int(page) = %d, want 3", got)
+	}
+	if got := a.Int("missing", 7); got != 7 {
+		t.Errorf("Int(missing) = %d, want the default 7", got)
+	}
+	if !a.Bool("all") || a.Bool("version") {
+		t.Errorf("Bool(all) = %v, Bool(version) = %v", a.Bool("all"), a.Bool("version"))
+	}
+	if got := a.String("version"); got != "" {
+		t.Errorf("String(version) = %q", got)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	tests := []struct {
+		spec *Spec
+		want string
+	}{
+		{nil, ".help"},
+		{&Spec{}, ".help"},
+		{testSpec, ".help <package> [query] [--version version] [--page n] [--all]"},
+		{restSpec, ".help <command> [text...]"},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.Usage(".help"); got != tt.want {
+			t.Errorf("Usage = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/docs"
+	"github.com/post04/dr-docso/settings"
+)
+
+const (
+	// announceLookupTimeout bounds the lookups made in one check of the release and vulnerability feeds.
+	announceLookupTimeout = time.Minute
+	// maxVulnAnnouncements is how many new vulnerabilities are announced per check, the rest wait for the next one.
+	maxVulnAnnouncements = 10
+)
+
+var (
+	// AnnounceChannels receive the Go release and security announcements.
+	AnnounceChannels []string
+	// AnnounceDatabase is the file the announced releases and vulnerabilities are saved to.
+	AnnounceDatabase string
+
+	// announced is only used by CheckAnnouncements.
+	announced struct {
+		Releases map[string]bool `json:"releases"`
+		Vulns    map[string]bool `json:"vulns"`
+	}
+)
+
+// LoadAnnouncements reads the announced releases and vulnerabilities from AnnounceDatabase.
+func LoadAnnouncements() error {
+	if AnnounceDatabase == "" {
+		return nil
+	}
+	fileBytes, err := os.ReadFile(AnnounceDatabase)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(fileBytes, &announced)
+}
+
+// saveAnnouncements writes the announced releases and vulnerabilities to AnnounceDatabase.
+func saveAnnouncements() {
+	if AnnounceDatabase == "" {
+		return
+	}
+	if err := settings.WriteJSON(AnnounceDatabase, &announced); err != nil {
+		log.Printf("could not save announcements: %s", err)
+	}
+}
+
+// CheckAnnouncements announces new Go releases and vulnerability database entries in AnnounceChannels.
+// What the feeds list on the first check is only remembered, not announced.
+func CheckAnnouncements(s Session, cycle time.Duration) {
+	ticker := time.NewTicker(cycle)
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), announceLookupTimeout)
+		checkReleases(ctx, s)
+		checkVulns(ctx, s)
+		cancel()
+		saveAnnouncements()
+	}
+}
+
+// checkReleases announces the Go releases that weren't announced yet.
+func checkReleases(ctx context.Context, s Session) {
+	releases, err := docs.Releases(ctx)
+	if err != nil {
+		log.Printf("could not check for Go releases: %s", err)
+		return
+	}
+	first := announced.Releases == nil
+	if first {
+		announced.Releases = make(map[string]bool)
+	}
+	// the feed lists the newest release first
+	for i := len(releases) - 1; i >= 0; i-- {
+		r := releases[i]
+		if announced.Releases[r.Version] {
+			continue
+		}
+		announced.Releases[r.Version] = true
+		if !first {
+			announce(s, releaseAnnouncement(r))
+		}
+	}
+}
+
+// checkVulns announces the vulnerability database entries that weren't announced yet.
+func checkVulns(ctx context.Context, s Session) {
+	index, err := docs.VulnIndex(ctx)
+	if err != nil {
+		log.Printf("could not check for vulnerabilities: %s", err)
+		return
+	}
+	first := announced.Vulns == nil
+	if first {
+		announced.Vulns = make(map[string]bool)
+	}
+	var ids []string
+	for _, entry := range index {
+		if announced.Vulns[entry.ID] {
+			continue
+		}
+		if first {
+			announced.Vulns[entry.ID] = true
+			continue
+		}
+		ids = append(ids, entry.ID)
+	}
+	sort.Strings(ids)
+	if len(ids) > maxVulnAnnouncements {
+		ids = ids[:maxVulnAnnouncements]
+	}
+	for _, id := range ids {
+		v, err := docs.GetVuln(ctx, id)
+		if err != nil {
+			log.Printf("could not get %s: %s", id, err)
+			return
+		}
+		announced.Vulns[id] = true
+		embed := vulnResponse(v)
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: "New Go vulnerability"}
+		announce(s, embed)
+	}
+}
+
+// releaseAnnouncement returns the announcement of a Go release.
+func releaseAnnouncement(r docs.Release) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s released", r.Version),
+		URL:         r.URL(),
+		Description: fmt.Sprintf("[Release notes](%s) · [Download](https://go.dev/dl/#%s)", r.URL(), r.Version),
+	}
+	if !r.Stable {
+		embed.Description += "\n*this is a pre-release*"
+	}
+	return embed
+}
+
+// announce sends an announcement to every channel in AnnounceChannels.
+func announce(s Session, embed *discordgo.MessageEmbed) {
+	for _, channelID := range AnnounceChannels {
+		if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+			log.Printf("could not send announcement: %s", err)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/docs"
+	"github.com/post04/dr-docso/settings"
+)
+
+const (
+	// maxAutolinks is the most symbol references answered for a single message.
+	maxAutolinks = 3
+	// autolinkCooldown is how long a channel has to wait between two auto-link replies.
+	autolinkCooldown = 10 * time.Second
+	// autolinkRepeat is how long the same symbol is not linked again in a channel.
+	autolinkRepeat = 2 * time.Minute
+	// autolinkTimeout bounds the lookups made for a single message.
+	autolinkTimeout = 20 * time.Second
+)
+
+// reSymbolRef matches doc link style references such as [strings.Builder],
+// [net/http.Client] or [strings.Builder.WriteString].
+var reSymbolRef = regexp.MustCompile(`\[((?:[a-zA-Z0-9_\-.~]+/)*[a-zA-Z0-9_\-]+)\.([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)?)\]`)
+
+// autolinkState keeps track of recent replies for spam protection.
+type autolinkState struct {
+	sync.Mutex
+	lastReply map[string]time.Time
+	recent    map[string]time.Time
+}
+
+var autolinks = &autolinkState{
+	lastReply: make(map[string]time.Time),
+	recent:    make(map[string]time.Time),
+}
+
+// symbolRef is a reference to a Go symbol found in a chat message.
+type symbolRef struct {
+	Pkg    string
+	Symbol string
+}
+
+// findSymbolRefs returns the unique bracketed symbol references in content.
+// Markdown links like [text](url) are skipped.
+func findSymbolRefs(content string) []symbolRef {
+	var refs []symbolRef
+	seen := make(map[string]bool)
+	for _, idx := range reSymbolRef.FindAllStringSubmatchIndex(content, -1) {
+		if idx[1] < len(content) && content[idx[1]] == '(' {
+			continue
+		}
+		ref := symbolRef{
+			Pkg:    content[idx[2]:idx[3]],
+			Symbol: content[idx[4]:idx[5]],
+		}
+		key := strings.ToLower(ref.Pkg + "." + ref.Symbol)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// resolvePkg expands short standard library names such as `http` to their full import path.
+func resolvePkg(name string) string {
+	if strings.ContainsRune(name, '/') {
+		return name
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if _, ok := StdlibCache[name]; ok {
+		return name
+	}
+	var best string
+	for path := range StdlibCache {
+		if !strings.HasSuffix(path, "/"+name) ||
+			strings.HasPrefix(path, "cmd/") ||
+			strings.Contains(path, "internal") ||
+			strings.Contains(path, "testdata") {
+			continue
+		}
+		if best == "" || len(path) < len(best) {
+			best = path
+		}
+	}
+	if best == "" {
+		return name
+	}
+	return best
+}
+
+// AutolinkListen answers symbol references in messages sent to channels where auto-linking is enabled.
+// It is meant to be called from the command handler's OnMessageHandler.
+func AutolinkListen(ctx context.Context, s Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || m.Author.Bot || m.GuildID == "" {
+		return
+	}
+	refs := findSymbolRefs(m.Content)
+	if len(refs) == 0 {
+		return
+	}
+	if len(refs) > maxAutolinks {
+		refs = refs[:maxAutolinks]
+	}
+
+	// channels closed to commands don't get auto-links either
+	if guild := Settings.Guild(m.GuildID); !guild.ChannelAllowed(m.ChannelID) || !guild.AutolinkEnabled(m.ChannelID) {
+		return
+	}
+
+	autolinks.Lock()
+	if time.Since(autolinks.lastReply[m.ChannelID]) < autolinkCooldown {
+		autolinks.Unlock()
+		return
+	}
+	var fresh []symbolRef
+	for _, ref := range refs {
+		key := m.ChannelID + ":" + strings.ToLower(ref.Pkg+"."+ref.Symbol)
+		if time.Since(autolinks.recent[key]) < autolinkRepeat {
+			continue
+		}
+		autolinks.recent[key] = time.Now()
+		fresh = append(fresh, ref)
+	}
+	if len(fresh) > 0 {
+		autolinks.lastReply[m.ChannelID] = time.Now()
+	}
+	autolinks.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, autolinkTimeout)
+	defer cancel()
+	for _, ref := range fresh {
+		embed := symbolResponse(ctx, resolvePkg(ref.Pkg), ref.Symbol)
+		if embed == nil {
+			continue
+		}
+		if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+			log.Printf("could not send auto-link: %s", err)
+			return
+		}
+	}
+}
+
+// symbolResponse generates a compact embed for a symbol, or nil if the symbol can't be found.
+func symbolResponse(ctx context.Context, pkg, symbol string) *discordgo.MessageEmbed {
+	doc, err := getDoc(ctx, pkg)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		t, name = "", symbol
+		sign    string
+		comment []string
+		notes   string
+	)
+	if i := strings.IndexByte(symbol, '.'); i >= 0 {
+		t, name = symbol[:i], symbol[i+1:]
+	}
+	for _, fn := range doc.Functions {
+		if !strings.EqualFold(fn.Name, name) {
+			continue
+		}
+		if t == "" && fn.Type == docs.FnNormal ||
+			t != "" && fn.Type == docs.FnMethod && strings.EqualFold(fn.MethodOf, t) {
+			sign, comment = fn.Signature, fn.Comments
+			notes = symbolNotes(fn.Deprecated, fn.Since)
+			if t != "" {
+				t = fn.MethodOf
+			}
+			name = fn.Name
+			break
+		}
+	}
+	if sign == "" && t == "" {
+		for _, typ := range doc.Types {
+			if strings.EqualFold(typ.Name, name) {
+				sign, comment, name = typ.Signature, typ.Comments, typ.Name
+				notes = symbolNotes(typ.Deprecated, typ.Since)
+				// type definitions can be very long, keep the first line only
+				if i := strings.IndexByte(sign, '\n'); i >= 0 {
+					sign = sign[:i]
+				}
+				break
+			}
+		}
+	}
+	if sign == "" {
+		return nil
+	}
+
+	anchor := name
+	if t != "" {
+		anchor = t + "." + name
+	}
+	link := fmt.Sprintf("%s#%s", doc.URL, anchor)
+	desc := fmt.Sprintf("`%s`", sign) + notes
+	if len(comment) > 0 {
+		desc += "\n" + firstSentence(comment[0])
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s.%s", pkg, anchor),
+		URL:         link,
+		Description: desc,
+	}
+}
+
+// firstSentence returns the first sentence of a paragraph.
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	if len(s) > 300 {
+		// don't cut a character in half
+		cut := 300
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		return s[:cut] + "..."
+	}
+	return s
+}
+
+// AutolinkArgs are the arguments of the autolink command.
+var AutolinkArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "mode", Optional: true},
+	},
+}
+
+// HandleAutolink is the handler for the autolink command.
+func HandleAutolink(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	if !a.Has("mode") {
+		s.ChannelMessageSendEmbed(m.ChannelID, autolinkHelp(prefix))
+		return
+	}
+	if m.GuildID == "" {
+		s.ChannelMessageSendEmbed(m.ChannelID, errResponse("Auto-link is only available in servers."))
+		return
+	}
+
+	var (
+		embed *discordgo.MessageEmbed
+		err   error
+	)
+	switch strings.ToLower(a.Get("mode")) {
+	case "on":
+		err = Settings.Update(m.GuildID, func(g *settings.Guild) {
+			g.AutolinkChannels = settings.Add(g.AutolinkChannels, m.ChannelID)
+		})
+		embed = &discordgo.MessageEmbed{
+			Title:       "Auto-link enabled",
+			Description: "References like `[strings.Builder]` in this channel will now be answered.",
+		}
+		if Settings.Guild(m.GuildID).AutolinkMode() == settings.AutolinkOff {
+			embed.Description += fmt.Sprintf("\n*note: auto-link is turned off for the whole server, use `%sconfig autolink channels` to turn it back on*", prefix)
+		}
+	case "off":
+		err = Settings.Update(m.GuildID, func(g *settings.Guild) {
+			g.AutolinkChannels = settings.Remove(g.AutolinkChannels, m.ChannelID)
+		})
+		embed = &discordgo.MessageEmbed{
+			Title:       "Auto-link disabled",
+			Description: "Symbol references in this channel will be ignored.",
+		}
+		if Settings.Guild(m.GuildID).AutolinkMode() == settings.AutolinkOn {
+			embed.Description = fmt.Sprintf("Auto-link is turned on for the whole server, use `%sconfig autolink channels` to only answer in selected channels.", prefix)
+		}
+	case "status":
+		state := "disabled"
+		if Settings.Guild(m.GuildID).AutolinkEnabled(m.ChannelID) {
+			state = "enabled"
+		}
+		embed = &discordgo.MessageEmbed{
+			Title:       "Auto-link status",
+			Description: fmt.Sprintf("Auto-link is %s in this channel.", state),
+		}
+	default:
+		embed = autolinkHelp(prefix)
+	}
+	if err != nil {
+		log.Printf("could not save settings: %s", err)
+		embed = errResponse("Could not save the settings, please try again later.")
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// autolinkHelp is the usage of the autolink command.
+func autolinkHelp(prefix string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "Help autolink",
+		Description: fmt.Sprintf("When enabled, messages containing references like `[strings.Builder]` or `[net/http.Client]` get a short summary of the symbol.\n\n"+
+			"%[1]sautolink on\n%[1]sautolink off\n%[1]sautolink status", prefix),
+	}
+}
@@ -0,0 +1,61 @@
+package bot_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/discordtest"
+	"github.com/post04/dr-docso/settings"
+)
+
+// useSettings replaces bot.Settings with an empty store until the test ends.
+func useSettings(t *testing.T) *settings.Store {
+	t.Helper()
+	store, err := settings.Open(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := bot.Settings
+	bot.Settings = store
+	t.Cleanup(func() { bot.Settings = old })
+	return store
+}
+
+func TestAutolinkListen(t *testing.T) {
+	useDocs(t)
+	store := useSettings(t)
+	store.Update("guild", func(g *settings.Guild) {
+		g.Autolink = settings.AutolinkOn
+		g.LockedChannels = []string{"locked"}
+	})
+	store.Update("allowing", func(g *settings.Guild) {
+		g.Autolink = settings.AutolinkOn
+		g.AllowedChannels = []string{"allowed"}
+	})
+	tests := []struct {
+		guild, channel string
+		reply          string
+	}{
+		{"guild", "open", "strings.Builder"},
+		{"guild", "locked", ""},
+		{"allowing", "allowed", "strings.Builder"},
+		{"allowing", "other", ""},
+	}
+	for _, tt := range tests {
+		s := discordtest.New()
+		msg := command("autolink-" + tt.channel)
+		msg.GuildID, msg.ChannelID = tt.guild, tt.channel
+		msg.Content = "use a [strings.Builder] for that"
+		bot.AutolinkListen(context.Background(), s, msg)
+
+		var reply string
+		if embed := s.LastEmbed(); embed != nil {
+			reply = embed.Title
+		}
+		if reply != tt.reply {
+			t.Errorf("reply in %s/%s = %q, want %q", tt.guild, tt.channel, reply, tt.reply)
+		}
+	}
+}
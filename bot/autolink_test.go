@@ -0,0 +1,29 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFirstSentence(t *testing.T) {
+	long := strings.Repeat("a", 299) + "é and more"
+	tests := []struct {
+		in, want string
+	}{
+		{"Builder builds strings. It is fast.", "Builder builds strings."},
+		{"Builder\n  builds   strings", "Builder builds strings"},
+		{"v1.2 is a version", "v1.2 is a version"},
+		// the cut falls in the middle of é, which is left out
+		{long, strings.Repeat("a", 299) + "..."},
+	}
+	for _, tt := range tests {
+		got := firstSentence(tt.in)
+		if got != tt.want {
+			t.Errorf("firstSentence(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("firstSentence(%q) = %q is not valid UTF-8", tt.in, got)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
+)
+
+// HandleCacheStats is the handler for the cache stats command.
+func HandleCacheStats(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	mux.Lock()
+	var stdlib int
+	for _, doc := range StdlibCache {
+		if doc != nil {
+			stdlib++
+		}
+	}
+	pkgs := len(PkgCache)
+	mux.Unlock()
+
+	s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+		Title:       "Cache stats",
+		Description: fmt.Sprintf("Standard library: %d/%d packages\nOther packages: %d", stdlib, len(StdlibCache), pkgs),
+	})
+}
+
+// HandleCacheFlush is the handler for the cache flush command.
+func HandleCacheFlush(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	mux.Lock()
+	for pkg := range StdlibCache {
+		StdlibCache[pkg] = nil
+	}
+	for pkg := range PkgCache {
+		delete(PkgCache, pkg)
+	}
+	mux.Unlock()
+
+	s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+		Title:       "Cache flushed",
+		Description: "Every package will be fetched again from pkg.go.dev on its next lookup.",
+	})
+}
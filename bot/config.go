@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/settings"
+)
+
+// Settings is the per-guild configuration store used by the bot.
+var Settings *settings.Store
+
+// CommandName resolves a command name or alias to the name of the top-level command, and reports
+// whether the command exists. It has to be set to the command handler's lookup before the config command is used.
+var CommandName func(name string) (string, bool)
+
+// ConfigArgs are the arguments of the config command.
+var ConfigArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "setting", Optional: true},
+		{Name: "value", Optional: true},
+	},
+}
+
+// HandleConfig is the handler for the config command.
+// The command handler only lets members with the Manage Server permission run it.
+func HandleConfig(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	if m.GuildID == "" {
+		s.ChannelMessageSendEmbed(m.ChannelID, errResponse("The config command is only available in servers."))
+		return
+	}
+
+	if !a.Has("setting") {
+		s.ChannelMessageSendEmbed(m.ChannelID, configResponse(Settings.Guild(m.GuildID), prefix))
+		return
+	}
+
+	var (
+		update func(g *settings.Guild)
+		arg    = a.Get("value")
+		err    error
+	)
+	switch key := strings.ToLower(a.Get("setting")); {
+	case !a.Has("value") && key == "reset":
+		err = Settings.Reset(m.GuildID)
+	case !a.Has("value"):
+		s.ChannelMessageSendEmbed(m.ChannelID, configHelp(prefix))
+		return
+	case key == "prefix":
+		update = func(g *settings.Guild) { g.Prefix = arg }
+	case key == "lock", key == "unlock", key == "allow", key == "disallow":
+		channelID := parseChannel(arg)
+		if channelID == "" {
+			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("`%s` is not a channel.", arg))
+			return
+		}
+		update = func(g *settings.Guild) {
+			switch key {
+			case "lock":
+				g.LockedChannels = settings.Add(g.LockedChannels, channelID)
+			case "unlock":
+				g.LockedChannels = settings.Remove(g.LockedChannels, channelID)
+			case "allow":
+				g.AllowedChannels = settings.Add(g.AllowedChannels, channelID)
+			case "disallow":
+				g.AllowedChannels = settings.Remove(g.AllowedChannels, channelID)
+			}
+		}
+	case key == "enable", key == "disable":
+		name, ok := CommandName(arg)
+		if !ok {
+			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("`%s` is not a command, use `%shelp` to get available commands.", arg, prefix))
+			return
+		}
+		if name == "config" {
+			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("The config command can't be disabled."))
+			return
+		}
+		update = func(g *settings.Guild) {
+			if key == "enable" {
+				g.DisabledCommands = settings.Remove(g.DisabledCommands, name)
+			} else {
+				g.DisabledCommands = settings.Add(g.DisabledCommands, name)
+			}
+		}
+	case key == "autolink":
+		mode := settings.AutolinkMode(strings.ToLower(arg))
+		if mode != settings.AutolinkOff && mode != settings.AutolinkChannels && mode != settings.AutolinkOn {
+			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("The auto-link mode must be one of `off`, `channels` or `on`."))
+			return
+		}
+		update = func(g *settings.Guild) { g.Autolink = mode }
+	case key == "language":
+		lang := strings.ToLower(arg)
+		if !validLanguage(lang) {
+			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("Unsupported language `%s`, supported languages: %s", arg, strings.Join(settings.Languages, ", ")))
+			return
+		}
+		update = func(g *settings.Guild) { g.Language = lang }
+	default:
+		s.ChannelMessageSendEmbed(m.ChannelID, configHelp(prefix))
+		return
+	}
+
+	if update != nil {
+		err = Settings.Update(m.GuildID, update)
+	}
+	if err != nil {
+		log.Printf("could not save settings: %s", err)
+		s.ChannelMessageSendEmbed(m.ChannelID, errResponse("Could not save the settings, please try again later."))
+		return
+	}
+	g := Settings.Guild(m.GuildID)
+	if g.Prefix != "" {
+		prefix = g.Prefix
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, configResponse(g, prefix))
+}
+
+// configResponse generates an embed showing a guild's configuration.
+func configResponse(g settings.Guild, prefix string) *discordgo.MessageEmbed {
+	channels := func(ids []string) string {
+		if len(ids) == 0 {
+			return "none"
+		}
+		mentions := make([]string, len(ids))
+		for i, id := range ids {
+			mentions[i] = "<#" + id + ">"
+		}
+		return strings.Join(mentions, ", ")
+	}
+	disabled := "none"
+	if len(g.DisabledCommands) > 0 {
+		disabled = strings.Join(g.DisabledCommands, ", ")
+	}
+	return &discordgo.MessageEmbed{
+		Title: "Server configuration",
+		Description: fmt.Sprintf("Prefix: `%s`\nAllowed channels: %s\nLocked channels: %s\nDisabled commands: %s\nAuto-link: %s (channels: %s)\nLanguage: %s",
+			prefix,
+			channels(g.AllowedChannels),
+			channels(g.LockedChannels),
+			disabled,
+			g.AutolinkMode(), channels(g.AutolinkChannels),
+			g.Lang()),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Use %sconfig help to see how to change these", prefix),
+		},
+	}
+}
+
+// configHelp is the usage of the config command.
+func configHelp(prefix string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "Help config",
+		Description: fmt.Sprintf("```\n"+
+			"%[1]sconfig                       show the current configuration\n"+
+			"%[1]sconfig prefix <prefix>       change the prefix\n"+
+			"%[1]sconfig lock <#channel>       refuse commands in a channel\n"+
+			"%[1]sconfig unlock <#channel>     accept commands in a channel again\n"+
+			"%[1]sconfig allow <#channel>      only accept commands in allowed channels\n"+
+			"%[1]sconfig disallow <#channel>   remove a channel from the allowed channels\n"+
+			"%[1]sconfig enable <command>      enable a command\n"+
+			"%[1]sconfig disable <command>     disable a command\n"+
+			"%[1]sconfig autolink <mode>       off, channels or on\n"+
+			"%[1]sconfig language <language>   change the response language\n"+
+			"%[1]sconfig reset                 restore the defaults\n"+
+			"```", prefix),
+	}
+}
+
+// parseChannel returns the ID of a channel mention or raw channel ID, or an empty string.
+func parseChannel(s string) string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "<#"), ">")
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return s
+}
+
+func validLanguage(lang string) bool {
+	for _, l := range settings.Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
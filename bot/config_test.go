@@ -0,0 +1,61 @@
+package bot_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/discordtest"
+	"github.com/post04/dr-docso/settings"
+)
+
+// commandNames resolves the names of a docs command with the alias d and a config command.
+func commandNames(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "docs", "d":
+		return "docs", true
+	case "config":
+		return "config", true
+	}
+	return "", false
+}
+
+func TestHandleConfig(t *testing.T) {
+	old := bot.CommandName
+	bot.CommandName = commandNames
+	t.Cleanup(func() { bot.CommandName = old })
+	tests := []struct {
+		args []string
+		// desc is a part of the reply's description
+		desc  string
+		check func(g settings.Guild) bool
+	}{
+		{nil, "Language: en", nil},
+		{[]string{"language", "EN"}, "Language: en", func(g settings.Guild) bool { return g.Language == "en" }},
+		{[]string{"language", "xx"}, "Unsupported language `xx`, supported languages: en", func(g settings.Guild) bool { return g.Language == "" }},
+		{[]string{"disable", "d"}, "Disabled commands: docs", func(g settings.Guild) bool { return !g.CommandEnabled("docs") }},
+		{[]string{"disable", "nothing"}, "`nothing` is not a command", func(g settings.Guild) bool { return len(g.DisabledCommands) == 0 }},
+		{[]string{"disable", "config"}, "The config command can't be disabled.", func(g settings.Guild) bool { return g.CommandEnabled("config") }},
+		{[]string{"lock", "<#123>"}, "Locked channels: <#123>", func(g settings.Guild) bool { return !g.ChannelAllowed("123") }},
+		{[]string{"lock", "general"}, "`general` is not a channel.", nil},
+		{[]string{"prefix", "?"}, "Prefix: `?`", func(g settings.Guild) bool { return g.Prefix == "?" }},
+		{[]string{"help"}, "config language <language>", nil},
+	}
+	for _, tt := range tests {
+		store := useSettings(t)
+		s := discordtest.New()
+		a, err := bot.ConfigArgs.Parse(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bot.HandleConfig(context.Background(), s, command("config"), ".", a)
+
+		if embed := s.LastEmbed(); embed == nil || !strings.Contains(embed.Description, tt.desc) {
+			t.Errorf("config %q replied %+v, want %q", tt.args, embed, tt.desc)
+		}
+		if tt.check != nil && !tt.check(store.Guild("guild")) {
+			t.Errorf("config %q stored %+v", tt.args, store.Guild("guild"))
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/docs"
+	"github.com/post04/dr-docso/glob"
+)
+
+// DirArgs are the arguments of the dir command.
+var DirArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package"},
+	},
+	Flags: []args.Flag{
+		{Name: "version", Kind: args.String},
+		{Name: "page", Kind: args.Int},
+	},
+}
+
+// HandleDir is the handler for the dir command.
+//
+// i.e, `.dir crypto`, `.dir crypto/*` (direct children only) or `.dir golang.org/x/tools/...`
+func HandleDir(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	path, filter := SplitDirPattern(a.Get("package"))
+	st := PagerState{
+		Kind:    "dir",
+		Package: path,
+		Version: a.String("version"),
+		Filter:  filter,
+		Owner:   m.Author.ID,
+	}
+	pkg := withVersion(path, a)
+	placeholder := startLoading(s, m, pkg, "pkg.go.dev")
+	if filter != "" {
+		if _, err := glob.Compile(filter); err != nil {
+			finishLoading(s, m, placeholder, errResponse("Error processing glob pattern:\n```\n%s\n```", err))
+			return
+		}
+	}
+	ctx, stats := withLookupStats(ctx)
+	p, err := dirPager(ctx, st)
+	switch {
+	case errors.Is(err, errNoItems) && filter != "":
+		finishLoading(s, m, placeholder, errResponse("`%s` has no packages matching `%s`", pkg, filter))
+		return
+	case errors.Is(err, errNoItems):
+		finishLoading(s, m, placeholder, errResponse("`%s` has no packages in its subdirectories", pkg))
+		return
+	case err != nil:
+		finishLoading(s, m, placeholder, docErrResponse(pkg, err))
+		return
+	}
+	p.SetPage(a.Int("page", 1))
+	sendPaginator(s, m, placeholder, p, stats)
+}
+
+// SplitDirPattern splits a directory pattern into the package path and a glob pattern
+// for the paths relative to it. A trailing /... lists everything, like an empty pattern.
+func SplitDirPattern(pattern string) (path, filter string) {
+	pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "/..."), "/")
+	elems := strings.Split(pattern, "/")
+	for i, elem := range elems {
+		if glob.IsPattern(elem) {
+			return strings.Join(elems[:i], "/"), strings.Join(elems[i:], "/")
+		}
+	}
+	return pattern, ""
+}
+
+// dirPager builds a paginator over the packages below a package, optionally filtered
+// by a glob pattern on their relative paths. Selecting a package shows its info.
+func dirPager(ctx context.Context, st PagerState) (*Paginator, error) {
+	pkg := st.Package
+	if st.Version != "" {
+		pkg += "@" + st.Version
+	}
+	match := func(string) bool { return true }
+	if st.Filter != "" {
+		m, err := glob.Compile(st.Filter)
+		if err != nil {
+			return nil, err
+		}
+		match = m.MatchString
+	}
+	doc, err := getDoc(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []docs.Subdirectory
+	for _, dir := range doc.Subdirectories {
+		if match(strings.TrimPrefix(dir.Path, st.Package+"/")) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, errNoItems
+	}
+	return &Paginator{
+		Title: fmt.Sprintf("Packages in %s", pkg),
+		URL:   doc.URL + "#section-directories",
+		Len:   len(dirs),
+		Render: func(i int) string {
+			line := fmt.Sprintf("[%s](%s%s)", strings.TrimPrefix(dirs[i].Path, st.Package+"/"), docs.BASE, dirs[i].Path)
+			if synopsis := firstSentence(dirs[i].Synopsis); synopsis != "" {
+				if len(synopsis) > 100 {
+					synopsis = synopsis[:100] + "..."
+				}
+				line += " — " + synopsis
+			}
+			return line
+		},
+		Select: func(ctx context.Context, i int) *discordgo.MessageEmbed {
+			return pkgResponse(ctx, dirs[i].Path)
+		},
+		PagerState: st,
+	}, nil
+}
@@ -1,13 +1,11 @@
 package bot
 
 import (
-	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-
-	"github.com/post04/dr-docso/docs"
 )
 
 const (
@@ -17,148 +15,107 @@ const (
 	destroyEmoji = "❌"
 )
 
-// ReactionListener is a struct for the reaction listener for pages
-type ReactionListener struct {
-	Type        string
-	CurrentPage int
-	PageLimit   int
-	UserID      string
-	Data        *docs.Doc
-	LastUsed    time.Time
-}
-
+// EditListener links a command message to the bot's reply, so editing or deleting the command updates the reply.
 type EditListener struct {
+	ChannelID  string
 	MessageID  string
 	LastEdited time.Time
 }
 
 var (
-	pageListeners = make(map[string]*ReactionListener)
 	editListeners = make(map[string]*EditListener)
+	editMux       sync.Mutex
 )
 
+// trackReply remembers reply as the answer to the command message commandID.
+func trackReply(commandID string, reply *discordgo.Message) {
+	editMux.Lock()
+	editListeners[commandID] = &EditListener{
+		ChannelID:  reply.ChannelID,
+		MessageID:  reply.ID,
+		LastEdited: time.Now(),
+	}
+	editMux.Unlock()
+}
+
+// trackedReply returns the reply to the command message commandID, or nil.
+func trackedReply(commandID string) *EditListener {
+	editMux.Lock()
+	defer editMux.Unlock()
+	return editListeners[commandID]
+}
+
+// HasReply reports whether the command message has a reply that is updated when the message is edited.
+func HasReply(commandID string) bool {
+	return trackedReply(commandID) != nil
+}
+
+// MessageDeleteListen deletes the bot's reply when the command message is deleted.
+func MessageDeleteListen(session *discordgo.Session, msg *discordgo.MessageDelete) {
+	HandleMessageDelete(Wrap(session), msg)
+}
+
+// HandleMessageDelete is MessageDeleteListen for any Session.
+func HandleMessageDelete(session Session, msg *discordgo.MessageDelete) {
+	editMux.Lock()
+	e, ok := editListeners[msg.ID]
+	delete(editListeners, msg.ID)
+	editMux.Unlock()
+	if !ok {
+		return
+	}
+	stopPaginator(e.MessageID)
+	if err := session.ChannelMessageDelete(e.ChannelID, e.MessageID); err != nil {
+		log.Printf("could not delete reply: %s", err)
+	}
+}
+
 // CheckListeners checks all active reaction listeners and kills inactive ones
 func CheckListeners(GcCycle time.Duration) {
 	garbageCollector := time.NewTicker(GcCycle)
 	for range garbageCollector.C {
-		for key, listener := range pageListeners {
-			if time.Since(listener.LastUsed) > time.Minute {
-				delete(pageListeners, key)
-			}
-		}
+		cleanPaginators()
+		editMux.Lock()
 		for key, listener := range editListeners {
 			if time.Since(listener.LastEdited) > time.Minute {
 				delete(editListeners, key)
 			}
 		}
-	}
-}
-
-func formatForMessage(page *ReactionListener) string {
-	s := ""
-	max := page.CurrentPage * 10
-	min := max - 10
-	curr := min
-	if page.Type == "functions" {
-		if max > len(page.Data.Functions) {
-			max = len(page.Data.Functions)
-		}
-		for _, function := range page.Data.Functions[min:max] {
-			curr++
-			s += fmt.Sprintf("\n%v.) %s", curr, function.Name)
-		}
-	}
-	if page.Type == "types" {
-		if max > len(page.Data.Types) {
-			max = len(page.Data.Types)
+		editMux.Unlock()
+		autolinks.Lock()
+		for key, last := range autolinks.recent {
+			if time.Since(last) > autolinkRepeat {
+				delete(autolinks.recent, key)
+			}
 		}
-		for _, dType := range page.Data.Types[min:max] {
-			curr++
-			s += fmt.Sprintf("\n%v.) %s", curr, dType.Name)
+		for channelID, last := range autolinks.lastReply {
+			if time.Since(last) > autolinkCooldown {
+				delete(autolinks.lastReply, channelID)
+			}
 		}
+		autolinks.Unlock()
 	}
-	return s
 }
 
 // ReactionListen listens for the reactions for a previously sent embed.
 func ReactionListen(session *discordgo.Session, reaction *discordgo.MessageReactionAdd) {
-	// if the message being reacted to is in the reaction map
-	if _, ok := pageListeners[reaction.MessageID]; ok {
-		// validating that the user reacting is indeed the user that owns the listener
-		if pageListeners[reaction.MessageID].UserID != reaction.UserID {
-			return
-		}
-		// switch the emoji name, name is misleading, basically -> ❌
-		switch reaction.Emoji.Name {
-		// when the reaction used is a left arrow (page decrease)
-		case leftArrow:
-			// update last used so the listener isn't deemed inactive
-			pageListeners[reaction.MessageID].LastUsed = time.Now()
-			// remove reaction, better user experience
-			session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, leftArrow, reaction.UserID)
-			// If page is already 1 (minimum it can be)
-			if pageListeners[reaction.MessageID].CurrentPage == 1 {
-				break
-			}
-			// decrease current page
-			pageListeners[reaction.MessageID].CurrentPage--
-			URL := pageListeners[reaction.MessageID].Data.URL
-			if pageListeners[reaction.MessageID].Type == "functions" {
-				URL += "#pkg-functions"
-			} else {
-				URL += "#pkg-types"
-			}
-			session.ChannelMessageEditEmbed(reaction.ChannelID, reaction.MessageID, &discordgo.MessageEmbed{
-				Title:       pageListeners[reaction.MessageID].Type,
-				Description: formatForMessage(pageListeners[reaction.MessageID]),
-				URL:         URL,
-				Footer: &discordgo.MessageEmbedFooter{
-					Text: fmt.Sprintf("Page %v/%v", pageListeners[reaction.MessageID].CurrentPage, pageListeners[reaction.MessageID].PageLimit),
-				},
-			})
-		case rightArrow:
-			// update last used so the listener isn't deemed unused and deleted
-			pageListeners[reaction.MessageID].LastUsed = time.Now()
-			// remove reaction for better user expirence
-			session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, rightArrow, reaction.UserID)
-			// if the page we're on right now is already the maximum page length we have, on page 7 out of 7
-			if pageListeners[reaction.MessageID].PageLimit == pageListeners[reaction.MessageID].CurrentPage {
-				break
-			}
-			// update current page by 1
-			pageListeners[reaction.MessageID].CurrentPage++
-			URL := pageListeners[reaction.MessageID].Data.URL
-			if pageListeners[reaction.MessageID].Type == "functions" {
-				URL += "#pkg-functions"
-			} else {
-				URL += "#pkg-types"
-			}
-			session.ChannelMessageEditEmbed(reaction.ChannelID, reaction.MessageID, &discordgo.MessageEmbed{
-				Title:       pageListeners[reaction.MessageID].Type,
-				URL:         URL,
-				Description: formatForMessage(pageListeners[reaction.MessageID]),
-				Footer: &discordgo.MessageEmbedFooter{
-					Text: fmt.Sprintf("Page %v/%v", pageListeners[reaction.MessageID].CurrentPage, pageListeners[reaction.MessageID].PageLimit),
-				},
-			})
-		case destroyEmoji:
-			// remove the specific page listener from the map, no longer listening for reactions
-			delete(pageListeners, reaction.MessageID)
-			// delete the embed the bot made, just cleans itself up.
-			session.ChannelMessageDelete(reaction.ChannelID, reaction.MessageID)
-		default:
-			break
-		}
+	HandleReaction(Wrap(session), reaction)
+}
+
+// HandleReaction is ReactionListen for any Session.
+func HandleReaction(session Session, reaction *discordgo.MessageReactionAdd) {
+	if p := paginator(reaction.MessageID); p != nil {
+		handlePaginatorReaction(session, reaction, p)
 		return
 	}
-	if reaction.UserID == session.State.User.ID || reaction.Emoji.Name != destroyEmoji {
+	if reaction.UserID == botID(session) || reaction.Emoji.Name != destroyEmoji {
 		return
 	}
 	msg, err := session.ChannelMessage(reaction.ChannelID, reaction.MessageID)
 	if err != nil {
 		return
 	}
-	if msg.Author.ID == session.State.User.ID && len(msg.Embeds) > 0 {
+	if msg.Author.ID == botID(session) && len(msg.Embeds) > 0 {
 		edit := &discordgo.MessageEmbed{
 			Title: msg.Embeds[0].Title,
 			URL:   msg.Embeds[0].URL,
@@ -0,0 +1,163 @@
+package bot_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/discordtest"
+)
+
+// sendFuncs shows the functions of strings, two pages, and returns the ID of the reply.
+func sendFuncs(t *testing.T, s *discordtest.Session, commandID string) string {
+	t.Helper()
+	a, err := bot.PagesArgs.Parse([]string{"strings"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot.HandleFuncsPages(context.Background(), s, command(commandID), ".", a)
+	reactions := s.CallsTo("MessageReactionAdd")
+	if len(reactions) == 0 {
+		t.Fatalf("no paginator was sent: %+v", s.Calls())
+	}
+	s.Reset()
+	return reactions[0].MessageID
+}
+
+func reaction(messageID, userID, emoji string) *discordgo.MessageReactionAdd {
+	return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		MessageID: messageID,
+		ChannelID: "channel",
+		UserID:    userID,
+		Emoji:     discordgo.Emoji{Name: emoji},
+	}}
+}
+
+func TestHandleReactionPaginator(t *testing.T) {
+	useDocs(t)
+	tests := []struct {
+		name string
+		// user and emojis are who reacts and with what, in order
+		user   string
+		emojis []string
+		calls  string
+		footer string
+		// deleted reports whether the paginator's message is gone
+		deleted bool
+	}{
+		{
+			name:   "next page",
+			user:   "user",
+			emojis: []string{"➡️"},
+			calls:  "MessageReactionRemove,ChannelMessageEditEmbed",
+			footer: "Page 2/2",
+		},
+		{
+			name:   "back to the first page",
+			user:   "user",
+			emojis: []string{"➡️", "⬅️"},
+			calls:  "MessageReactionRemove,ChannelMessageEditEmbed,MessageReactionRemove,ChannelMessageEditEmbed",
+			footer: "Page 1/2",
+		},
+		{
+			name:   "before the first page",
+			user:   "user",
+			emojis: []string{"⬅️"},
+			calls:  "MessageReactionRemove",
+		},
+		{
+			name:   "other user",
+			user:   "someone",
+			emojis: []string{"➡️", "❌"},
+		},
+		{
+			name:   "unknown emoji",
+			user:   "user",
+			emojis: []string{"👍"},
+		},
+		{
+			name:    "delete",
+			user:    "user",
+			emojis:  []string{"❌"},
+			calls:   "ChannelMessageDelete",
+			deleted: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := discordtest.New()
+			msgID := sendFuncs(t, s, "pages-"+string(rune('a'+i)))
+			for _, emoji := range tt.emojis {
+				bot.HandleReaction(s, reaction(msgID, tt.user, emoji))
+			}
+
+			calls := s.Calls()
+			if got := methods(calls); got != tt.calls {
+				t.Fatalf("calls = %s, want %s", got, tt.calls)
+			}
+			for _, c := range calls {
+				if c.MessageID != msgID {
+					t.Errorf("%s on message %s, want %s", c.Method, c.MessageID, msgID)
+				}
+			}
+			if tt.footer != "" {
+				if embed := s.LastEmbed(); !strings.HasPrefix(embed.Footer.Text, tt.footer) {
+					t.Errorf("footer = %q, want %q", embed.Footer.Text, tt.footer)
+				}
+			}
+			if deleted := s.Message(msgID) == nil; deleted != tt.deleted {
+				t.Errorf("message deleted = %v, want %v", deleted, tt.deleted)
+			}
+			// a deleted paginator doesn't listen anymore
+			if tt.deleted {
+				s.Reset()
+				bot.HandleReaction(s, reaction(msgID, tt.user, "➡️"))
+				if calls := s.Calls(); len(calls) != 0 {
+					t.Errorf("reaction on a deleted paginator: %+v", calls)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleReactionCollapse(t *testing.T) {
+	embed := &discordgo.MessageEmbed{Title: "strings", URL: "https://pkg.go.dev/strings", Description: "Package strings"}
+	tests := []struct {
+		name   string
+		author string
+		user   string
+		emoji  string
+		calls  string
+	}{
+		{"collapse", "bot", "user", "❌", "ChannelMessage,ChannelMessageEditEmbed"},
+		{"other emoji", "bot", "user", "👍", ""},
+		{"bot reaction", "bot", "bot", "❌", ""},
+		{"not the bot's message", "user", "user", "❌", "ChannelMessage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := discordtest.New()
+			s.AddMessage(&discordgo.Message{
+				ID:        "reply",
+				ChannelID: "channel",
+				Author:    &discordgo.User{ID: tt.author},
+				Embeds:    []*discordgo.MessageEmbed{embed},
+			})
+			bot.HandleReaction(s, reaction("reply", tt.user, tt.emoji))
+
+			if got := methods(s.Calls()); got != tt.calls {
+				t.Fatalf("calls = %s, want %s", got, tt.calls)
+			}
+			// the reply is collapsed to its title and link
+			if edits := s.CallsTo("ChannelMessageEditEmbed"); len(edits) > 0 {
+				got := edits[0].Embed
+				if got.Title != embed.Title || got.URL != embed.URL || got.Description != "" {
+					t.Errorf("collapsed embed = %+v", got)
+				}
+			}
+		})
+	}
+}
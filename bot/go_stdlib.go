@@ -2,6 +2,7 @@
 package bot
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -9,15 +10,23 @@ import (
 )
 
 // getDoc is a wrapper for docs.GetDoc that also implements caching for stdlib packages.
-func getDoc(pkg string) (*docs.Doc, error) {
+func getDoc(ctx context.Context, pkg string) (*docs.Doc, error) {
+	if SafeMode && !IsStdlib(pkg) {
+		return nil, ErrSafeMode
+	}
+	mux.Lock()
 	if doc, ok := PkgCache[pkg]; ok {
+		mux.Unlock()
+		recordLookup(ctx, true)
 		return doc.Doc, nil
 	}
+	doc, ok := StdlibCache[pkg]
+	mux.Unlock()
+	recordLookup(ctx, doc != nil)
 
 	var err error
-	doc, ok := StdlibCache[pkg]
 	if doc == nil {
-		doc, err = docs.GetDoc(pkg)
+		doc, err = docs.GetDoc(ctx, pkg)
 		if err != nil {
 			return nil, err
 		}
@@ -47,11 +56,13 @@ func getDoc(pkg string) (*docs.Doc, error) {
 func CleanTempDocs(GcCycle time.Duration) {
 	garbageCollector := time.NewTicker(GcCycle)
 	for range garbageCollector.C {
+		mux.Lock()
 		for key, cache := range PkgCache {
 			if time.Since(cache.Created) > time.Minute*10 {
 				delete(PkgCache, key)
 			}
 		}
+		mux.Unlock()
 	}
 }
 
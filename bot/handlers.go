@@ -1,25 +1,50 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
-	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
 	"github.com/post04/dr-docso/docs"
 	"github.com/post04/dr-docso/glob"
 )
 
-// DocsHelpEmbed is the help for the docs command.
-var DocsHelpEmbed = &discordgo.MessageEmbed{
-	Title: "Docs help",
+// DocsArgs are the arguments of the docs command.
+var DocsArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package", Optional: true},
+		{Name: "query", Optional: true},
+	},
+	Flags: []args.Flag{
+		{Name: "version", Kind: args.String},
+	},
 }
 
-func HandleDocSend(s *discordgo.Session, m *discordgo.MessageCreate, prefix string) {
-	msg := HandleDoc(s, m.Content, m.ChannelID)
+// PagesArgs are the arguments of the funcs and types commands.
+var PagesArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package"},
+		{Name: "filter", Optional: true},
+	},
+	Flags: []args.Flag{
+		{Name: "version", Kind: args.String},
+		{Name: "page", Kind: args.Int},
+		{Name: "no-deprecated", Kind: args.Bool},
+	},
+}
+
+// HandleDocSend is the handler for the docs command.
+func HandleDocSend(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	placeholder := startLoading(s, m, docsPackage(a), "pkg.go.dev")
+	ctx, stats := withLookupStats(ctx)
+	msg := HandleDoc(ctx, a, prefix)
+	addStats(msg, stats)
 
-	embedM, err := s.ChannelMessageSendEmbed(m.ChannelID, msg)
+	embedM, err := finishLoading(s, m, placeholder, msg)
 	if err != nil {
 		log.Printf("Could not send message: %v", err)
 		return
@@ -28,84 +53,95 @@ func HandleDocSend(s *discordgo.Session, m *discordgo.MessageCreate, prefix stri
 	err = s.MessageReactionAdd(embedM.ChannelID, embedM.ID, destroyEmoji)
 	if err != nil {
 		log.Printf("could not add reaction: %s", err)
-		return
-	}
-	editListeners[m.ID] = &EditListener{
-		MessageID:  embedM.ID,
-		LastEdited: time.Now(),
 	}
 }
 
-func HandleDocUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
-	e, ok := editListeners[m.ID]
-	if !ok {
-		return
+// HandleDoc generates the response for the docs command.
+func HandleDoc(ctx context.Context, a *args.Args, prefix string) *discordgo.MessageEmbed {
+	pkg, query := a.Get("package"), a.Get("query")
+	switch {
+	case pkg == "": // only the invocation
+		return docsHelp(prefix)
+	case query != "": // pkg + func
+		return determineResponse(ctx, withVersion(pkg, a), query)
+	case !strings.ContainsRune(pkg, '.'): // package search
+		return pkgResponse(ctx, withVersion(pkg, a))
+	}
+
+	// io.Reader.Read -> io Reader.Read
+	split := strings.SplitN(pkg, ".", 2)
+	msg := determineResponse(ctx, withVersion(split[0], a), split[1])
+	// if there is an error, we try defaulting to pkgResponse
+	if msg.Title == "Error" {
+		msg = pkgResponse(ctx, withVersion(pkg, a))
 	}
+	return msg
+}
 
-	msg := HandleDoc(s, m.Content, m.ChannelID)
-	if _, err := s.ChannelMessageEditEmbed(m.ChannelID, e.MessageID, msg); err != nil {
-		log.Printf("could not edit message: %s", err)
-		return
+// docsPackage returns the package the docs command will most likely look up.
+func docsPackage(a *args.Args) string {
+	pkg := a.Get("package")
+	if !a.Has("query") && !isCached(withVersion(pkg, a)) && strings.ContainsRune(pkg, '.') {
+		pkg = strings.SplitN(pkg, ".", 2)[0]
 	}
-	e.LastEdited = time.Now()
+	return withVersion(pkg, a)
 }
 
-// HandleDoc  is the handler for the doc command.
-func HandleDoc(s *discordgo.Session, content string, channelID string) *discordgo.MessageEmbed {
-	var msg *discordgo.MessageEmbed
-	fields := strings.Fields(content)
-	switch len(fields) {
-	case 0: // probably impossible
-		return nil
-	case 1: // only the invocation
-		msg = helpShortResponse() // TODO: probably should just use the variable here.
-	case 2: // invocation + arg
-		// package search
-		if !strings.ContainsRune(fields[1], '.') {
-			msg = pkgResponse(fields[1])
-			break
-		}
-		// io.Reader.Read -> io Reader.Read
-		split := strings.SplitN(fields[1], ".", 2)
-		msg = determineResponse(split[0], split[1])
-		// if there is an error, we try defaulting to pkgResponse
-		if msg.Title == "Error" {
-			msg = pkgResponse(fields[1])
-		}
-	case 3: // invocation + pkg + func
-		msg = determineResponse(fields[1], fields[2])
-	default:
-		msg = errResponse("Too many arguments.")
+// withVersion appends the --version flag to a package path, pkg.go.dev serves versions as pkg@version.
+func withVersion(pkg string, a *args.Args) string {
+	if v := a.String("version"); v != "" {
+		return pkg + "@" + v
 	}
-
-	return msg
+	return pkg
 }
 
-// helpShortResponse returns the docs command's help embed.
-func helpShortResponse() *discordgo.MessageEmbed {
-	return DocsHelpEmbed
+// docsHelp returns the docs command's help embed.
+func docsHelp(prefix string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "Docs help",
+		Description: fmt.Sprintf(`__**Examples:**__
+%[1]sdocs strings
+%[1]sdocs strings equalfold
+%[1]sdocs strings builder
+%[1]sdocs strings builder.*
+%[1]sdocs strings *.writestring
+%[1]sdocs strings {has,trim}*
+%[1]sdocs strings [!A-M]*`, prefix),
+	}
 }
 
-func determineResponse(pkg, s string) *discordgo.MessageEmbed {
-	s = strings.Title(s)
+func determineResponse(ctx context.Context, pkg, s string) *discordgo.MessageEmbed {
+	// raw regular expressions may contain dots, so they are never split into type.method
+	if strings.HasPrefix(s, glob.RawPrefix) {
+		return queryResponse(ctx, pkg, s)
+	}
+	if !glob.IsPattern(s) {
+		s = strings.Title(s)
+	}
 	if strings.ContainsRune(s, '.') {
 		split := strings.SplitN(s, ".", 2)
-		return methodResponse(pkg, split[0], split[1])
+		return methodResponse(ctx, pkg, split[0], split[1])
 	}
-	return queryResponse(pkg, s)
+	return queryResponse(ctx, pkg, s)
 }
 
 // pkgResponse generates an embed with general information about a package.
-func pkgResponse(pkg string) *discordgo.MessageEmbed {
-	doc, err := getDoc(pkg)
+func pkgResponse(ctx context.Context, pkg string) *discordgo.MessageEmbed {
+	doc, err := getDoc(ctx, pkg)
+	if err != nil {
+		return docErrResponse(pkg, err)
+	}
+	// the module details are extras, the info is still useful without them
+	module, err := docs.CompleteModule(ctx, pkg, doc.Module)
 	if err != nil {
-		return errResponse("An error occured when requesting the page for the package `%s`", pkg)
+		log.Printf("could not look up the module of %s: %s", pkg, err)
 	}
 
 	embed := &discordgo.MessageEmbed{
 		Title:       fmt.Sprintf("Info for %s", pkg),
 		URL:         fmt.Sprintf("%s", doc.URL),
 		Description: fmt.Sprintf("Types: %v\nFunctions: %v", len(doc.Types), len(doc.Functions)),
+		Fields:      moduleFields(module),
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: doc.URL,
 		},
@@ -119,31 +155,74 @@ func pkgResponse(pkg string) *discordgo.MessageEmbed {
 	return embed
 }
 
+// moduleFields returns the embed fields describing a module, leaving out what isn't known.
+func moduleFields(m docs.Module) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true})
+		}
+	}
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	add("Module", m.Path)
+	if m.Version != "" {
+		version := m.Version
+		switch {
+		case m.Stable:
+			version += " (stable)"
+		case m.Tagged:
+			version += " (tagged)"
+		default:
+			version += " (untagged)"
+		}
+		add("Version", version)
+	}
+	if m.Latest != m.Version {
+		add("Latest", m.Latest)
+	}
+	add("Published", m.Published)
+	add("License", m.License)
+	if m.License != "" {
+		add("Redistributable", yesNo(m.Redistributable))
+	}
+	if m.GoVersion != "" {
+		add("Go version", "go "+m.GoVersion)
+	}
+	add("Repository", m.Repository)
+	return fields
+}
+
 // methodResponse generates an embed for a method query.
 //
 // i.e, `.docs regexp Regexp.Match`
-func methodResponse(pkg, t, name string) *discordgo.MessageEmbed {
-	if strings.ContainsAny(t, regexpSpecials) || strings.ContainsAny(name, regexpSpecials) {
-		return methodGlobResponse(pkg, t, name)
+func methodResponse(ctx context.Context, pkg, t, name string) *discordgo.MessageEmbed {
+	if glob.IsPattern(t) || glob.IsPattern(name) {
+		return methodGlobResponse(ctx, pkg, t, name)
 	}
 
-	doc, err := getDoc(pkg)
+	doc, err := getDoc(ctx, pkg)
 	if err != nil {
-		return errResponse("Error while getting the page for the package `%s`", pkg)
+		return docErrResponse(pkg, err)
 	}
 	if len(doc.Functions) == 0 {
 		return errResponse("Package `%s` seems to have no functions", pkg)
 	}
 
-	var msg, link string
+	var msg, link, title string
 	for _, fn := range doc.Functions {
 		//  not matching
 		if fn.Type != docs.FnMethod || !strings.EqualFold(fn.Name, name) || !strings.EqualFold(fn.MethodOf, t) {
 			continue
 		}
 
+		title = fmt.Sprintf("%s: func(%s) %s", pkg, fn.Receiver, fn.Name)
 		link = fmt.Sprintf("%s#%s.%s", doc.URL, fn.MethodOf, fn.Name)
-		msg += fmt.Sprintf("`%s`", fn.Signature)
+		msg += fmt.Sprintf("`%s`", fn.Signature) + symbolNotes(fn.Deprecated, fn.Since)
 		if len(fn.Comments) == 0 {
 			msg += "\n*no info*"
 			continue
@@ -158,7 +237,7 @@ func methodResponse(pkg, t, name string) *discordgo.MessageEmbed {
 		msg = fmt.Sprintf("%s\n\n*note: the message is trimmed to fit the 2k character limit*", msg[:1950])
 	}
 	return &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("%s: func(%s) %s", pkg, t, name),
+		Title:       title,
 		URL:         link,
 		Description: msg,
 		Footer: &discordgo.MessageEmbedFooter{
@@ -168,7 +247,7 @@ func methodResponse(pkg, t, name string) *discordgo.MessageEmbed {
 }
 
 // methodGlobResponse generates an embed for a glob pattern describing type.method.
-func methodGlobResponse(pkg, t, name string) *discordgo.MessageEmbed {
+func methodGlobResponse(ctx context.Context, pkg, t, name string) *discordgo.MessageEmbed {
 	reT, err := glob.Compile(t)
 	if err != nil {
 		return errResponse("Error processing glob pattern:\n```\n%s\n```", err)
@@ -177,9 +256,9 @@ func methodGlobResponse(pkg, t, name string) *discordgo.MessageEmbed {
 	if err != nil {
 		return errResponse("Error processing glob pattern:\n```\n%s\n```", err)
 	}
-	doc, err := getDoc(pkg)
+	doc, err := getDoc(ctx, pkg)
 	if err != nil {
-		return errResponse("An error occurred while getting the page for the package `%s`", pkg)
+		return docErrResponse(pkg, err)
 	}
 
 	if len(doc.Functions) == 0 || len(doc.Types) == 0 {
@@ -217,23 +296,23 @@ func methodGlobResponse(pkg, t, name string) *discordgo.MessageEmbed {
 // queryResponse generates the response for a query.
 //
 // i.e, `.docs strings Builder`
-func queryResponse(pkg, name string) *discordgo.MessageEmbed {
-	if strings.ContainsAny(name, regexpSpecials) {
-		return queryGlobResponse(pkg, name)
+func queryResponse(ctx context.Context, pkg, name string) *discordgo.MessageEmbed {
+	if glob.IsPattern(name) {
+		return queryGlobResponse(ctx, pkg, name)
 	}
-	doc, err := getDoc(pkg)
+	doc, err := getDoc(ctx, pkg)
 	if err != nil {
-		return errResponse("An error occurred while fetching the page for pkg `%s`", pkg)
+		return docErrResponse(pkg, err)
 	}
 
-	var msg string
+	var msg, title string
 	for _, fn := range doc.Functions {
 		if fn.Type != docs.FnNormal || !strings.EqualFold(fn.Name, name) {
 			continue
 		}
 
-		name = fn.Name
-		msg += fmt.Sprintf("`%s`", fn.Signature)
+		name, title = fn.Name, fn.FullName()
+		msg += fmt.Sprintf("`%s`", fn.Signature) + symbolNotes(fn.Deprecated, fn.Since)
 		if len(fn.Comments) == 0 {
 			msg += "\n*no information*"
 		} else {
@@ -250,7 +329,11 @@ func queryResponse(pkg, name string) *discordgo.MessageEmbed {
 				continue
 			}
 
+			name, title = t.Name, t.FullName()
 			msg += fmt.Sprintf("```go\n%s\n```\n", t.Signature)
+			if notes := symbolNotes(t.Deprecated, t.Since); notes != "" {
+				msg += notes[1:] + "\n"
+			}
 			if len(t.Comments) == 0 {
 				msg += "*no information available*\n"
 				continue
@@ -266,7 +349,7 @@ func queryResponse(pkg, name string) *discordgo.MessageEmbed {
 		msg = fmt.Sprintf("%s\n\n*note: the message was trimmed to fit the 2k character limit*", msg[:1950])
 	}
 	return &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("%s: %s", pkg, name),
+		Title:       fmt.Sprintf("%s: %s", pkg, title),
 		URL:         fmt.Sprintf("%s#%s", doc.URL, name),
 		Description: msg,
 		Footer: &discordgo.MessageEmbedFooter{
@@ -275,17 +358,15 @@ func queryResponse(pkg, name string) *discordgo.MessageEmbed {
 	}
 }
 
-const regexpSpecials = "*|[]()+{}-"
-
 // queryGlobResponse is the same as queryResponse but it allows globbing.
-func queryGlobResponse(pkg, name string) *discordgo.MessageEmbed {
+func queryGlobResponse(ctx context.Context, pkg, name string) *discordgo.MessageEmbed {
 	r, err := glob.Compile(name)
 	if err != nil {
-		return errResponse("error parsing glob pattern")
+		return errResponse("Error processing glob pattern:\n```\n%s\n```", err)
 	}
-	doc, err := getDoc(pkg)
+	doc, err := getDoc(ctx, pkg)
 	if err != nil {
-		return errResponse("Error while fetching the page for the package `%s`", pkg)
+		return docErrResponse(pkg, err)
 	}
 
 	var msg string
@@ -332,110 +413,126 @@ func queryGlobResponse(pkg, name string) *discordgo.MessageEmbed {
 	}
 }
 
-// HandleFuncsPages is the handler fo the getfuncs command
-func HandleFuncsPages(s *discordgo.Session, m *discordgo.MessageCreate, prefix string) {
-	fields := strings.Fields(m.Content)
-	switch len(fields) {
-	case 0: // probably impossible
-		return
-	case 1: // send a help command here
-		s.ChannelMessageSendEmbed(m.ChannelID, PagesShortResponse("getfuncs", prefix))
-		return
-	case 2: // command + pkg (send page if possible)
-		doc, err := getDoc(fields[1])
-		if err != nil || doc == nil {
-			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("Error while getting the page for the package `%s`", fields[1]))
-			return
-		}
-		if len(doc.Functions) == 0 {
-			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("The package `%s` has no functions", fields[1]))
-			return
-		}
-		page := &ReactionListener{
-			Type:        "functions",
-			CurrentPage: 1,
-			PageLimit:   calcLimit(len(doc.Functions), 10),
-			UserID:      m.Author.ID,
-			Data:        doc,
-			LastUsed:    time.Now(),
-		}
-		m, err := s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
-			Title:       "functions",
-			URL:         doc.URL + "#pkg-functions",
-			Description: formatForMessage(page),
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: "Page 1/" + fmt.Sprint(page.PageLimit),
-			},
-		})
-		if err != nil {
+// HandleFuncsPages is the handler fo the funcs command
+func HandleFuncsPages(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	sendPages(ctx, s, m, a, "functions")
+}
+
+// HandleTypesPages is the handler fo the types command
+func HandleTypesPages(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	sendPages(ctx, s, m, a, "types")
+}
+
+// errNoItems is returned by paginator builders when there is nothing to show.
+var errNoItems = errors.New("no items")
+
+// sendPages sends the first requested page of the functions or types of a package.
+func sendPages(ctx context.Context, s Session, m *discordgo.MessageCreate, a *args.Args, listType string) {
+	st := PagerState{
+		Kind:           listType,
+		Package:        a.Get("package"),
+		Version:        a.String("version"),
+		Filter:         a.Get("filter"),
+		Owner:          m.Author.ID,
+		HideDeprecated: a.Bool("no-deprecated"),
+	}
+	pkg := withVersion(st.Package, a)
+	placeholder := startLoading(s, m, pkg, "pkg.go.dev")
+	if st.Filter != "" {
+		if _, err := glob.Compile(st.Filter); err != nil {
+			finishLoading(s, m, placeholder, errResponse("Error processing glob pattern:\n```\n%s\n```", err))
 			return
 		}
-		s.MessageReactionAdd(m.ChannelID, m.ID, leftArrow)
-		s.MessageReactionAdd(m.ChannelID, m.ID, rightArrow)
-		s.MessageReactionAdd(m.ChannelID, m.ID, destroyEmoji)
-		pageListeners[m.ID] = page
+	}
+	ctx, stats := withLookupStats(ctx)
+	p, err := docListPager(ctx, st)
+	switch {
+	case errors.Is(err, errNoItems) && st.Filter != "":
+		finishLoading(s, m, placeholder, errResponse("The package `%s` has no %s matching `%s`", pkg, listType, st.Filter))
+		return
+	case errors.Is(err, errNoItems):
+		finishLoading(s, m, placeholder, errResponse("The package `%s` has no %s", pkg, listType))
 		return
-	default: // too many arguments
-		s.ChannelMessageSendEmbed(m.ChannelID, PagesShortResponse("getfuncs", prefix))
+	case err != nil:
+		finishLoading(s, m, placeholder, docErrResponse(pkg, err))
 		return
 	}
+	p.SetPage(a.Int("page", 1))
+	sendPaginator(s, m, placeholder, p, stats)
 }
 
-// HandleTypesPages is the handler fo the gettypes command
-func HandleTypesPages(s *discordgo.Session, m *discordgo.MessageCreate, prefix string) {
-	fields := strings.Fields(m.Content)
-	switch len(fields) {
-	case 0: // probably impossible
-		return
-	case 1: // send a help command here
-		s.ChannelMessageSendEmbed(m.ChannelID, PagesShortResponse("gettypes", prefix))
-		return
-	case 2: // command + pkg (send page if possible)
-		doc, err := getDoc(fields[1])
-		if err != nil || doc == nil {
-			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("Error while getting the page for the package `%s`", fields[1]))
-			return
-		}
-		if len(doc.Functions) == 0 {
-			s.ChannelMessageSendEmbed(m.ChannelID, errResponse("The package `%s` has no types", fields[1]))
-			return
+// docListPager builds a paginator over the functions or types of a package, optionally filtered by a glob pattern.
+func docListPager(ctx context.Context, st PagerState) (*Paginator, error) {
+	pkg := st.Package
+	if st.Version != "" {
+		pkg += "@" + st.Version
+	}
+	match := func(string) bool { return true }
+	if st.Filter != "" {
+		m, err := glob.Compile(st.Filter)
+		if err != nil {
+			return nil, err
 		}
-		page := &ReactionListener{
-			Type:        "types",
-			CurrentPage: 1,
-			PageLimit:   calcLimit(len(doc.Types), 10),
-			UserID:      m.Author.ID,
-			Data:        doc,
-			LastUsed:    time.Now(),
+		match = m.MatchString
+	}
+	doc, err := getDoc(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if st.Kind == "types" {
+		for _, t := range doc.Types {
+			if match(t.Name) && !(st.HideDeprecated && t.Deprecated != "") {
+				names = append(names, t.FullName()+badges(t.Deprecated, t.Since))
+			}
 		}
-		m, err := s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
-			Title:       "types",
-			URL:         doc.URL + "#pkg-types",
-			Description: formatForMessage(page),
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: "Page 1/" + fmt.Sprint(page.PageLimit),
-			},
-		})
-		if err != nil {
-			return
+	} else {
+		for _, fn := range doc.Functions {
+			if match(fn.Name) && !(st.HideDeprecated && fn.Deprecated != "") {
+				names = append(names, fn.FullName()+badges(fn.Deprecated, fn.Since))
+			}
 		}
-		s.MessageReactionAdd(m.ChannelID, m.ID, leftArrow)
-		s.MessageReactionAdd(m.ChannelID, m.ID, rightArrow)
-		s.MessageReactionAdd(m.ChannelID, m.ID, destroyEmoji)
-		pageListeners[m.ID] = page
-		return
-	default: // too many arguments
-		s.ChannelMessageSendEmbed(m.ChannelID, PagesShortResponse("gettypes", prefix))
-		return
 	}
+	if len(names) == 0 {
+		return nil, errNoItems
+	}
+	return &Paginator{
+		Title:      st.Kind,
+		URL:        doc.URL + "#pkg-" + st.Kind,
+		Len:        len(names),
+		Render:     func(i int) string { return names[i] },
+		PagerState: st,
+	}, nil
 }
 
-// PagesShortResponse is the error response for the commands to show pages of types or funcs
-func PagesShortResponse(state, prefix string) *discordgo.MessageEmbed {
-	return &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("Help %s", state),
-		Description: fmt.Sprintf("It seems you didn't have enough arguments, so here's an example\n\n%s%s strings", prefix, state),
-	}
+// docErrResponse generates an embed explaining why the documentation of pkg could not be fetched.
+func docErrResponse(pkg string, err error) *discordgo.MessageEmbed {
+	var docErr *docs.Error
+	errors.As(err, &docErr)
+	switch {
+	case errors.Is(err, ErrSafeMode):
+		return errResponse("`%s` is not a standard library package; only the standard library is available on this bot.", pkg)
+	case errors.Is(err, docs.ErrNotFound):
+		msg := errResponse("The package `%s` could not be found on pkg.go.dev.", pkg)
+		if full := resolvePkg(pkg); full != pkg {
+			msg.Description += fmt.Sprintf(" Did you mean `%s`?", full)
+		}
+		return msg
+	case errors.Is(err, docs.ErrRedirected):
+		return errResponse("`%s` is not a package, pkg.go.dev redirected to `%s`. Try looking that up instead.", pkg, docErr.RedirectedTo)
+	case errors.Is(err, docs.ErrRateLimited):
+		if docErr.RetryAfter > 0 {
+			return errResponse("pkg.go.dev is rate limiting the bot, please try again in %v.", docErr.RetryAfter)
+		}
+		return errResponse("pkg.go.dev is rate limiting the bot, please try again in a minute.")
+	case errors.Is(err, context.DeadlineExceeded):
+		return errResponse("pkg.go.dev took too long to answer for `%s`, please try again later.", pkg)
+	case errors.Is(err, docs.ErrUnavailable):
+		return errResponse("pkg.go.dev could not be reached, please try again later.")
+	case errors.Is(err, docs.ErrParse):
+		return errResponse("The page for `%s` could not be read; pkg.go.dev may have changed its layout.", pkg)
+	}
+	return errResponse("An error occurred while getting the page for the package `%s`", pkg)
 }
 
 // errResponse is like fmt.Sprintf, formats a message and returns an embed.
@@ -445,3 +542,33 @@ func errResponse(format string, args ...interface{}) *discordgo.MessageEmbed {
 		Description: fmt.Sprintf(format, args...),
 	}
 }
+
+// badges returns the markers shown after a symbol in lists, e.g. ` · go1.21 · deprecated`.
+func badges(deprecated, since string) string {
+	var b string
+	if since != "" {
+		b += " · " + since
+	}
+	if deprecated != "" {
+		b += " · *deprecated*"
+	}
+	return b
+}
+
+// symbolNotes returns the lines shown below a symbol's signature: when it was added and whether it is deprecated.
+func symbolNotes(deprecated, since string) string {
+	var notes string
+	if since != "" {
+		notes += fmt.Sprintf("\n*added in %s*", since)
+	}
+	if deprecated == "" {
+		return notes
+	}
+	if r := docs.Replacement(deprecated); r != "" {
+		return notes + fmt.Sprintf("\n⚠️ **Deprecated**, use `%s` instead.", r)
+	}
+	if deprecated != "deprecated" {
+		return notes + "\n⚠️ **Deprecated**: " + deprecated
+	}
+	return notes + "\n⚠️ **Deprecated**"
+}
@@ -0,0 +1,220 @@
+package bot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/discordtest"
+	"github.com/post04/dr-docso/docs"
+)
+
+// useDocs serves the saved pages of docs/testdata in place of pkg.go.dev and the module proxy
+// until the test ends. The documentation cache is flushed so every test starts with a cold cache.
+func useDocs(t *testing.T) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkg := strings.TrimPrefix(r.URL.Path, "/")
+		http.ServeFile(w, r, filepath.Join("..", "docs", "testdata", strings.ReplaceAll(pkg, "/", "_")+".html"))
+	}))
+	oldBase, oldProxy := docs.BaseURL, docs.ProxyURL
+	docs.BaseURL, docs.ProxyURL = srv.URL+"/", srv.URL+"/proxy/"
+	flushCache()
+	t.Cleanup(func() {
+		docs.BaseURL, docs.ProxyURL = oldBase, oldProxy
+		flushCache()
+		srv.Close()
+	})
+}
+
+func flushCache() {
+	bot.HandleCacheFlush(context.Background(), discordtest.New(), &discordgo.MessageCreate{Message: &discordgo.Message{}}, ".", nil)
+}
+
+// command returns a command message sent by the user "user" in a guild channel.
+func command(id string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        id,
+		ChannelID: "channel",
+		GuildID:   "guild",
+		Author:    &discordgo.User{ID: "user", Username: "gopher"},
+	}}
+}
+
+// methods returns the methods of the recorded calls, in order.
+func methods(calls []discordtest.Call) string {
+	names := make([]string, len(calls))
+	for i, c := range calls {
+		names[i] = c.Method
+	}
+	return strings.Join(names, ",")
+}
+
+func TestHandleDoc(t *testing.T) {
+	useDocs(t)
+	tests := []struct {
+		args  []string
+		title string
+		url   string
+		// desc is a part of the description
+		desc string
+	}{
+		{nil, "Docs help", "", ".docs strings builder"},
+		{[]string{"strings"}, "Info for strings", "https://pkg.go.dev/strings", "Types: 2\nFunctions: 13"},
+		{[]string{"strings", "equalfold"}, "strings: EqualFold", "https://pkg.go.dev/strings#EqualFold", "func EqualFold"},
+		{[]string{"strings.Builder"}, "strings: Builder", "https://pkg.go.dev/strings#Builder", "type Builder struct"},
+		{[]string{"strings", "builder.writestring"}, "strings: func(Builder) WriteString", "https://pkg.go.dev/strings#Builder.WriteString", "WriteString"},
+		{[]string{"strings", "*.Len"}, "Matches", "https://pkg.go.dev/strings", "Len"},
+		{[]string{"strings", "nothing"}, "Error", "", "No type or function `Nothing` found in package `strings`"},
+		{[]string{"strings", "[a-"}, "Error", "", "Error processing glob pattern"},
+		{[]string{"example.com/missing"}, "Error", "", "could not be found on pkg.go.dev"},
+	}
+	for _, tt := range tests {
+		a, err := bot.DocsArgs.Parse(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		embed := bot.HandleDoc(context.Background(), a, ".")
+		if embed.Title != tt.title || embed.URL != tt.url || !strings.Contains(embed.Description, tt.desc) {
+			t.Errorf("HandleDoc(%q) = %q %q\n%s\nwant %q %q containing %q", tt.args, embed.Title, embed.URL, embed.Description, tt.title, tt.url, tt.desc)
+		}
+	}
+}
+
+func TestHandleDocSend(t *testing.T) {
+	useDocs(t)
+	tests := []struct {
+		name string
+		args []string
+		// calls are the methods called on the session
+		calls string
+		title string
+	}{
+		{
+			name:  "fetched",
+			args:  []string{"strings", "cut"},
+			calls: "ChannelTyping,ChannelMessageSendEmbed,ChannelMessageEditEmbed,MessageReactionAdd",
+			title: "strings: Cut",
+		},
+		{
+			name:  "cached",
+			args:  []string{"strings", "clone"},
+			calls: "ChannelTyping,ChannelMessageSendEmbed,MessageReactionAdd",
+			title: "strings: Clone",
+		},
+		{
+			name:  "help",
+			calls: "ChannelTyping,ChannelMessageSendEmbed,MessageReactionAdd",
+			title: "Docs help",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := discordtest.New()
+			a, err := bot.DocsArgs.Parse(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bot.HandleDocSend(context.Background(), s, command("docs-"+tt.name), ".", a)
+
+			calls := s.Calls()
+			if got := methods(calls); got != tt.calls {
+				t.Fatalf("calls = %s, want %s", got, tt.calls)
+			}
+			if embed := s.LastEmbed(); embed.Title != tt.title {
+				t.Errorf("reply title = %q, want %q", embed.Title, tt.title)
+			}
+			// the reply, not the command, can be deleted with the reaction
+			if last := calls[len(calls)-1]; last.Emoji != "❌" || last.MessageID == "docs-"+tt.name {
+				t.Errorf("reaction = %+v", last)
+			}
+		})
+	}
+}
+
+func TestHandleFuncsPages(t *testing.T) {
+	useDocs(t)
+	tests := []struct {
+		name string
+		args []string
+		// reactions are the controls added to the reply
+		reactions []string
+		title     string
+		footer    string
+		desc      string
+	}{
+		{
+			name:      "two pages",
+			args:      []string{"strings"},
+			reactions: []string{"⬅️", "➡️", "❌"},
+			title:     "functions",
+			footer:    "Page 1/2",
+			desc:      "1.) Clone",
+		},
+		{
+			name:      "page flag",
+			args:      []string{"strings", "--page", "2"},
+			reactions: []string{"⬅️", "➡️", "❌"},
+			title:     "functions",
+			footer:    "Page 2/2",
+			desc:      "11.) WriteString · go1.10",
+		},
+		{
+			name:      "filter",
+			args:      []string{"strings", "Write*"},
+			reactions: []string{"❌"},
+			title:     "functions",
+			footer:    "Page 1/1",
+			desc:      "1.) WriteString · go1.10",
+		},
+		{
+			name:  "no matches",
+			args:  []string{"strings", "Nothing*"},
+			title: "Error",
+			desc:  "The package `strings` has no functions matching `Nothing*`",
+		},
+		{
+			name:  "bad filter",
+			args:  []string{"strings", "[a-"},
+			title: "Error",
+			desc:  "Error processing glob pattern",
+		},
+		{
+			name:  "missing package",
+			args:  []string{"example.com/missing"},
+			title: "Error",
+			desc:  "could not be found on pkg.go.dev",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := discordtest.New()
+			a, err := bot.PagesArgs.Parse(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bot.HandleFuncsPages(context.Background(), s, command("funcs-"+tt.name), ".", a)
+
+			embed := s.LastEmbed()
+			if embed.Title != tt.title || !strings.Contains(embed.Description, tt.desc) {
+				t.Errorf("reply = %q\n%s\nwant %q containing %q", embed.Title, embed.Description, tt.title, tt.desc)
+			}
+			if tt.footer != "" && (embed.Footer == nil || !strings.HasPrefix(embed.Footer.Text, tt.footer)) {
+				t.Errorf("footer = %+v, want %q", embed.Footer, tt.footer)
+			}
+			var reactions []string
+			for _, c := range s.CallsTo("MessageReactionAdd") {
+				reactions = append(reactions, c.Emoji)
+			}
+			if strings.Join(reactions, " ") != strings.Join(tt.reactions, " ") {
+				t.Errorf("reactions = %q, want %q", reactions, tt.reactions)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/docs"
+	"github.com/post04/dr-docso/glob"
+)
+
+// ImportsArgs are the arguments of the imports and importedby commands.
+var ImportsArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package"},
+		{Name: "filter", Optional: true},
+	},
+	Flags: []args.Flag{
+		{Name: "version", Kind: args.String},
+		{Name: "page", Kind: args.Int},
+	},
+}
+
+// HandleImports is the handler for the imports command.
+func HandleImports(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	sendImports(ctx, s, m, a, "imports")
+}
+
+// HandleImportedBy is the handler for the importedby command.
+func HandleImportedBy(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	sendImports(ctx, s, m, a, "importedby")
+}
+
+// sendImports sends a paginator over the imports or the importers of a package.
+func sendImports(ctx context.Context, s Session, m *discordgo.MessageCreate, a *args.Args, kind string) {
+	st := PagerState{
+		Kind:    kind,
+		Package: a.Get("package"),
+		Version: a.String("version"),
+		Filter:  a.Get("filter"),
+		Owner:   m.Author.ID,
+	}
+	pkg := withVersion(st.Package, a)
+	placeholder := startLoading(s, m, pkg, "pkg.go.dev")
+	if st.Filter != "" {
+		if _, err := glob.CompilePath(st.Filter); err != nil {
+			finishLoading(s, m, placeholder, errResponse("Error processing glob pattern:\n```\n%s\n```", err))
+			return
+		}
+	}
+	p, err := importsPager(ctx, st)
+	switch {
+	case errors.Is(err, errNoItems) && kind == "imports":
+		finishLoading(s, m, placeholder, errResponse("The package `%s` doesn't import any packages", pkg))
+		return
+	case errors.Is(err, errNoItems):
+		finishLoading(s, m, placeholder, errResponse("No known packages import `%s`", pkg))
+		return
+	case err != nil:
+		finishLoading(s, m, placeholder, docErrResponse(pkg, err))
+		return
+	}
+	p.SetPage(a.Int("page", 1))
+	sendPaginator(s, m, placeholder, p, nil)
+}
+
+// importsPager builds a paginator over the imports or importers of a package, optionally filtered by a glob pattern.
+// Selecting a package shows its info.
+func importsPager(ctx context.Context, st PagerState) (*Paginator, error) {
+	pkg := st.Package
+	if st.Version != "" {
+		pkg += "@" + st.Version
+	}
+	var (
+		paths []string
+		total int
+		title string
+		err   error
+	)
+	if st.Kind == "imports" {
+		paths, err = docs.Imports(ctx, pkg)
+		total = len(paths)
+		title = fmt.Sprintf("Imports of %s (%d)", pkg, total)
+	} else {
+		paths, total, err = docs.ImportedBy(ctx, pkg)
+		title = fmt.Sprintf("%s is imported by %d packages", pkg, total)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if st.Filter != "" {
+		m, err := glob.CompilePath(st.Filter)
+		if err != nil {
+			return nil, err
+		}
+		filtered := paths[:0]
+		for _, path := range paths {
+			if m.MatchString(path) {
+				filtered = append(filtered, path)
+			}
+		}
+		paths = filtered
+	}
+	if len(paths) == 0 {
+		return nil, errNoItems
+	}
+	if st.Kind == "importedby" && total > len(paths) {
+		title += fmt.Sprintf(", showing %d", len(paths))
+	}
+	return &Paginator{
+		Title: title,
+		URL:   docs.BASE + pkg + "?tab=" + st.Kind,
+		Len:   len(paths),
+		Render: func(i int) string {
+			return fmt.Sprintf("[%s](%s%s)", paths[i], docs.BASE, paths[i])
+		},
+		Select: func(ctx context.Context, i int) *discordgo.MessageEmbed {
+			return pkgResponse(ctx, paths[i])
+		},
+		PagerState: st,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type lookupStatsKey struct{}
+
+// lookupStats counts the cache hits and misses of the getDoc calls made for a command.
+type lookupStats struct {
+	sync.Mutex
+	start        time.Time
+	hits, misses int
+}
+
+// withLookupStats returns a context in which getDoc records its cache hits and misses.
+func withLookupStats(ctx context.Context) (context.Context, *lookupStats) {
+	stats := &lookupStats{start: time.Now()}
+	return context.WithValue(ctx, lookupStatsKey{}, stats), stats
+}
+
+// recordLookup records a cache hit or miss in the context's stats, if any.
+func recordLookup(ctx context.Context, hit bool) {
+	stats, ok := ctx.Value(lookupStatsKey{}).(*lookupStats)
+	if !ok {
+		return
+	}
+	stats.Lock()
+	if hit {
+		stats.hits++
+	} else {
+		stats.misses++
+	}
+	stats.Unlock()
+}
+
+func (l *lookupStats) String() string {
+	l.Lock()
+	defer l.Unlock()
+	elapsed := fmt.Sprintf("%.2fs", time.Since(l.start).Seconds())
+	switch {
+	case l.misses == 0:
+		return elapsed + " • cache hit"
+	case l.hits == 0:
+		return elapsed + " • cache miss"
+	}
+	return fmt.Sprintf("%s • %d cached, %d fetched", elapsed, l.hits, l.misses)
+}
+
+// addStats appends the elapsed time and cache usage to the footer of embed.
+// Nothing is added if no package was looked up.
+func addStats(embed *discordgo.MessageEmbed, stats *lookupStats) {
+	if stats == nil {
+		return
+	}
+	stats.Lock()
+	looked := stats.hits+stats.misses > 0
+	stats.Unlock()
+	if !looked {
+		return
+	}
+	if embed.Footer == nil {
+		embed.Footer = &discordgo.MessageEmbedFooter{}
+	}
+	parts := []string{stats.String()}
+	if embed.Footer.Text != "" {
+		parts = append([]string{embed.Footer.Text}, parts...)
+	}
+	embed.Footer.Text = strings.Join(parts, " • ")
+}
+
+// isCached reports whether the documentation of pkg is cached.
+func isCached(pkg string) bool {
+	mux.Lock()
+	defer mux.Unlock()
+	if _, ok := PkgCache[pkg]; ok {
+		return true
+	}
+	return StdlibCache[pkg] != nil
+}
+
+// startLoading shows that a lookup of pkg from source started: a typing indicator, and a placeholder
+// embed if the package isn't cached. When the command was edited, the previous reply is
+// used as the placeholder. It returns the placeholder, or nil if none was sent.
+func startLoading(s Session, m *discordgo.MessageCreate, pkg, source string) *discordgo.Message {
+	if err := s.ChannelTyping(m.ChannelID); err != nil {
+		log.Printf("could not start typing: %s", err)
+	}
+	var placeholder *discordgo.Message
+	if e := trackedReply(m.ID); e != nil {
+		placeholder = &discordgo.Message{ID: e.MessageID, ChannelID: e.ChannelID}
+	}
+	if pkg == "" || isCached(pkg) {
+		return placeholder
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Fetching…",
+		Description: fmt.Sprintf("Getting `%s` from %s, this can take a few seconds.", pkg, source),
+	}
+	var err error
+	if placeholder != nil {
+		_, err = s.ChannelMessageEditEmbed(placeholder.ChannelID, placeholder.ID, embed)
+	} else {
+		placeholder, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	}
+	if err != nil {
+		log.Printf("could not send placeholder: %s", err)
+	}
+	return placeholder
+}
+
+// finishLoading replaces the placeholder with embed, or sends embed if there's no placeholder.
+// The reply is tracked so that editing the command message runs it again.
+func finishLoading(s Session, m *discordgo.MessageCreate, placeholder *discordgo.Message, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	var (
+		reply *discordgo.Message
+		err   error
+	)
+	if placeholder == nil {
+		reply, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	} else {
+		// an edited command may have shown a pager before, its state is reset
+		stopPaginator(placeholder.ID)
+		reply, err = s.ChannelMessageEditEmbed(placeholder.ChannelID, placeholder.ID, embed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	trackReply(m.ID, reply)
+	return reply, nil
+}
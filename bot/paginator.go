@@ -0,0 +1,378 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/settings"
+)
+
+const (
+	// defaultPerPage is the number of items shown on a page unless the paginator says otherwise.
+	defaultPerPage = 10
+	// pagerTimeout is how long a paginator keeps its items in memory after it was last used.
+	// Saved paginators are rebuilt from their state when they're used again.
+	pagerTimeout = 2 * time.Minute
+	// pagerStateTTL is how long the state of an unused paginator is kept.
+	pagerStateTTL = 24 * time.Hour
+	// pagerLookupTimeout bounds the lookups made when a paginator is rebuilt or an item is selected.
+	pagerLookupTimeout = 20 * time.Second
+	// pagerSaveDelay is how long changes to paginators are collected before they're saved.
+	pagerSaveDelay = 10 * time.Second
+)
+
+// numberEmojis select the items on the current page.
+var numberEmojis = []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣", "7️⃣", "8️⃣", "9️⃣", "🔟"}
+
+// PagerState is the part of a paginator that is saved, enough to build it again after a restart.
+type PagerState struct {
+	// Kind selects the builder in pagerKinds. Paginators without a kind are not saved.
+	Kind    string `json:"kind,omitempty"`
+	Package string `json:"package,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Compare is the older version of an API diff.
+	Compare string `json:"compare,omitempty"`
+	Filter  string `json:"filter,omitempty"`
+	// HideDeprecated leaves deprecated symbols out of symbol lists.
+	HideDeprecated bool      `json:"hideDeprecated,omitempty"`
+	Page           int       `json:"page"`
+	Owner          string    `json:"owner"`
+	LastUsed       time.Time `json:"lastUsed"`
+}
+
+// Paginator shows a list of items a page at a time. The owner flips through
+// the pages with the arrow reactions and deletes the message with ❌.
+type Paginator struct {
+	Title string
+	URL   string
+	// Len is the number of items.
+	Len int
+	// Render returns the text shown for the item at index i.
+	// It is nil when the paginator was unloaded and has to be rebuilt from its state.
+	Render func(i int) string
+	// Select returns the response to selecting the item at index i with a number reaction.
+	// Items can't be selected if it is nil.
+	Select func(ctx context.Context, i int) *discordgo.MessageEmbed
+	// PerPage is the number of items on a page, defaultPerPage if zero.
+	// Pages with selectable items show at most len(numberEmojis) items.
+	PerPage int
+	PagerState
+}
+
+// pagerKinds builds paginators from their saved state.
+var pagerKinds = map[string]func(ctx context.Context, st PagerState) (*Paginator, error){
+	"functions":  docListPager,
+	"types":      docListPager,
+	"imports":    importsPager,
+	"importedby": importsPager,
+	"dir":        dirPager,
+	"versions":   versionsPager,
+	"apidiff":    apiDiffPager,
+	"vuln":       vulnPager,
+}
+
+var (
+	pageListeners = make(map[string]*Paginator)
+	pageMux       sync.Mutex
+	// PagerDatabase is the file paginator states are saved to, they're only kept in memory if it's empty.
+	PagerDatabase string
+	pagerSaveMux  sync.Mutex
+	// pagerSaveTimer is the pending save, nil if there is none.
+	pagerSaveTimer *time.Timer
+)
+
+// perPage returns the number of items on a page.
+func (p *Paginator) perPage() int {
+	perPage := p.PerPage
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	if p.Select != nil && perPage > len(numberEmojis) {
+		perPage = len(numberEmojis)
+	}
+	return perPage
+}
+
+// Pages returns the number of pages.
+func (p *Paginator) Pages() int {
+	return calcLimit(p.Len, p.perPage())
+}
+
+// SetPage changes the current page, clamped to the available pages.
+// It reports whether the page changed.
+func (p *Paginator) SetPage(page int) bool {
+	if page > p.Pages() {
+		page = p.Pages()
+	}
+	if page < 1 {
+		page = 1
+	}
+	changed := page != p.Page
+	p.Page = page
+	return changed
+}
+
+// Embed renders the current page.
+func (p *Paginator) Embed() *discordgo.MessageEmbed {
+	perPage := p.perPage()
+	p.SetPage(p.Page)
+	min := (p.Page - 1) * perPage
+	max := min + perPage
+	if max > p.Len {
+		max = p.Len
+	}
+	var b strings.Builder
+	for i := min; i < max; i++ {
+		fmt.Fprintf(&b, "\n%v.) %s", i+1, p.Render(i))
+	}
+	footer := fmt.Sprintf("Page %v/%v", p.Page, p.Pages())
+	if p.Select != nil {
+		footer += " · react with a number to open an item"
+	}
+	return &discordgo.MessageEmbed{
+		Title:       p.Title,
+		URL:         p.URL,
+		Description: b.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: footer,
+		},
+	}
+}
+
+// Listen adds the controls to msg, which shows the paginator, and starts listening for reactions.
+func (p *Paginator) Listen(s Session, msg *discordgo.Message) {
+	p.LastUsed = time.Now()
+	pageMux.Lock()
+	pageListeners[msg.ID] = p
+	pageMux.Unlock()
+	savePagers()
+	if p.Pages() > 1 {
+		s.MessageReactionAdd(msg.ChannelID, msg.ID, leftArrow)
+		s.MessageReactionAdd(msg.ChannelID, msg.ID, rightArrow)
+	}
+	s.MessageReactionAdd(msg.ChannelID, msg.ID, destroyEmoji)
+	if p.Select != nil {
+		n := p.perPage()
+		if n > p.Len {
+			n = p.Len
+		}
+		for _, emoji := range numberEmojis[:n] {
+			s.MessageReactionAdd(msg.ChannelID, msg.ID, emoji)
+		}
+	}
+}
+
+// sendPaginator shows the paginator as the reply to m, replacing the placeholder if there is one.
+func sendPaginator(s Session, m *discordgo.MessageCreate, placeholder *discordgo.Message, p *Paginator, stats *lookupStats) {
+	embed := p.Embed()
+	addStats(embed, stats)
+	reply, err := finishLoading(s, m, placeholder, embed)
+	if err != nil {
+		log.Printf("could not send pages: %s", err)
+		return
+	}
+	p.Listen(s, reply)
+}
+
+// paginator returns the paginator shown in the message, or nil.
+func paginator(messageID string) *Paginator {
+	pageMux.Lock()
+	defer pageMux.Unlock()
+	return pageListeners[messageID]
+}
+
+// stopPaginator stops listening for reactions on the message.
+func stopPaginator(messageID string) {
+	pageMux.Lock()
+	_, ok := pageListeners[messageID]
+	delete(pageListeners, messageID)
+	pageMux.Unlock()
+	if ok {
+		savePagers()
+	}
+}
+
+// cleanPaginators unloads the items of unused paginators and forgets the ones that
+// can't be rebuilt or weren't used for a long time.
+func cleanPaginators() {
+	pageMux.Lock()
+	changed := false
+	for key, p := range pageListeners {
+		idle := time.Since(p.LastUsed)
+		switch {
+		case idle > pagerStateTTL, idle > pagerTimeout && (p.Kind == "" || PagerDatabase == ""):
+			delete(pageListeners, key)
+			changed = true
+		case idle > pagerTimeout && p.Render != nil:
+			pageListeners[key] = &Paginator{PagerState: p.PagerState}
+		}
+	}
+	pageMux.Unlock()
+	if changed {
+		savePagers()
+	}
+}
+
+// rebuildPaginator builds an unloaded paginator again from its state.
+func rebuildPaginator(messageID string, p *Paginator) (*Paginator, error) {
+	build, ok := pagerKinds[p.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown paginator kind %q", p.Kind)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pagerLookupTimeout)
+	defer cancel()
+	rebuilt, err := build(ctx, p.PagerState)
+	if err != nil {
+		return nil, err
+	}
+	rebuilt.PagerState = p.PagerState
+	pageMux.Lock()
+	pageListeners[messageID] = rebuilt
+	pageMux.Unlock()
+	return rebuilt, nil
+}
+
+// handlePaginatorReaction applies a reaction to the paginator shown in the message.
+func handlePaginatorReaction(s Session, reaction *discordgo.MessageReactionAdd, p *Paginator) {
+	if p.Owner != reaction.UserID {
+		return
+	}
+	emoji := reaction.Emoji.Name
+	if emoji == destroyEmoji {
+		stopPaginator(reaction.MessageID)
+		s.ChannelMessageDelete(reaction.ChannelID, reaction.MessageID)
+		return
+	}
+	number := -1
+	for i, e := range numberEmojis {
+		if e == emoji {
+			number = i
+		}
+	}
+	if emoji != leftArrow && emoji != rightArrow && number < 0 {
+		return
+	}
+	// remove the reaction so the same control can be used again
+	s.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, emoji, reaction.UserID)
+	if p.Render == nil {
+		var err error
+		if p, err = rebuildPaginator(reaction.MessageID, p); err != nil {
+			log.Printf("could not rebuild paginator: %s", err)
+			return
+		}
+	}
+
+	// concurrent reactions change the same paginator, the page is read and rendered under the lock
+	pageMux.Lock()
+	page := p.Page
+	switch emoji {
+	case leftArrow:
+		page--
+	case rightArrow:
+		page++
+	}
+	p.LastUsed = time.Now()
+	changed := p.SetPage(page)
+	page = p.Page
+	var embed *discordgo.MessageEmbed
+	if changed {
+		embed = p.Embed()
+	}
+	pageMux.Unlock()
+	savePagers()
+
+	if number >= 0 {
+		selectItem(s, reaction.ChannelID, p, (page-1)*p.perPage()+number)
+		return
+	}
+	if !changed {
+		return
+	}
+	if _, err := s.ChannelMessageEditEmbed(reaction.ChannelID, reaction.MessageID, embed); err != nil {
+		log.Printf("could not change page: %s", err)
+	}
+}
+
+// selectItem sends the response to selecting the item at index i.
+func selectItem(s Session, channelID string, p *Paginator, i int) {
+	if p.Select == nil || i >= p.Len {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pagerLookupTimeout)
+	defer cancel()
+	if _, err := s.ChannelMessageSendEmbed(channelID, p.Select(ctx, i)); err != nil {
+		log.Printf("could not send selected item: %s", err)
+	}
+}
+
+// LoadPagers loads the paginator states saved in PagerDatabase.
+// They are rebuilt when somebody uses their controls.
+func LoadPagers() error {
+	if PagerDatabase == "" {
+		return nil
+	}
+	fileBytes, err := os.ReadFile(PagerDatabase)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	states := make(map[string]PagerState)
+	if err := json.Unmarshal(fileBytes, &states); err != nil {
+		return err
+	}
+	pageMux.Lock()
+	for id, st := range states {
+		pageListeners[id] = &Paginator{PagerState: st}
+	}
+	pageMux.Unlock()
+	return nil
+}
+
+// savePagers saves the paginators after pagerSaveDelay, so the changes made in the meantime,
+// like flipping through pages, are written at once.
+func savePagers() {
+	if PagerDatabase == "" {
+		return
+	}
+	pagerSaveMux.Lock()
+	defer pagerSaveMux.Unlock()
+	if pagerSaveTimer == nil {
+		pagerSaveTimer = time.AfterFunc(pagerSaveDelay, SavePagers)
+	}
+}
+
+// SavePagers writes the state of every paginator that can be rebuilt to PagerDatabase,
+// without waiting for the pending save.
+func SavePagers() {
+	if PagerDatabase == "" {
+		return
+	}
+	pagerSaveMux.Lock()
+	defer pagerSaveMux.Unlock()
+	if pagerSaveTimer != nil {
+		pagerSaveTimer.Stop()
+		pagerSaveTimer = nil
+	}
+	states := make(map[string]PagerState)
+	pageMux.Lock()
+	for id, p := range pageListeners {
+		if p.Kind != "" {
+			states[id] = p.PagerState
+		}
+	}
+	pageMux.Unlock()
+	if err := settings.WriteJSON(PagerDatabase, states); err != nil {
+		log.Printf("could not save paginators: %s", err)
+	}
+}
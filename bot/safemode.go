@@ -0,0 +1,23 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+)
+
+// SafeMode restricts lookups to standard library packages.
+var SafeMode bool
+
+// ErrSafeMode is returned by getDoc for non-stdlib packages while SafeMode is on.
+var ErrSafeMode = errors.New("only standard library packages are available in safe mode")
+
+// IsStdlib reports whether pkg is a standard library package, optionally followed by @version.
+func IsStdlib(pkg string) bool {
+	if i := strings.IndexByte(pkg, '@'); i >= 0 {
+		pkg = pkg[:i]
+	}
+	mux.Lock()
+	_, ok := StdlibCache[pkg]
+	mux.Unlock()
+	return ok
+}
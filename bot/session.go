@@ -0,0 +1,52 @@
+package bot
+
+import "github.com/bwmarrin/discordgo"
+
+// Session is the part of a Discord session the bot uses.
+// Wrap adapts a *discordgo.Session; discordtest has a fake that records the calls.
+type Session interface {
+	ChannelMessage(channelID, messageID string) (*discordgo.Message, error)
+	ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error)
+	ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string) error
+	ChannelTyping(channelID string) error
+	MessageReactionAdd(channelID, messageID, emojiID string) error
+	MessageReactionRemove(channelID, messageID, emojiID, userID string) error
+	UserChannelPermissions(userID, channelID string) (int64, error)
+	// BotUser returns the user the bot is logged in as, or nil if it isn't known yet.
+	BotUser() *discordgo.User
+	// Role returns a role of a guild.
+	Role(guildID, roleID string) (*discordgo.Role, error)
+}
+
+// discordSession adapts *discordgo.Session to Session.
+type discordSession struct {
+	*discordgo.Session
+}
+
+// Wrap returns s as a Session.
+func Wrap(s *discordgo.Session) Session {
+	return discordSession{s}
+}
+
+func (s discordSession) BotUser() *discordgo.User {
+	if s.State == nil {
+		return nil
+	}
+	return s.State.User
+}
+
+func (s discordSession) Role(guildID, roleID string) (*discordgo.Role, error) {
+	if s.State == nil {
+		return nil, discordgo.ErrNilState
+	}
+	return s.State.Role(guildID, roleID)
+}
+
+// botID returns the user ID of the bot, or an empty string if it isn't known yet.
+func botID(s Session) string {
+	if u := s.BotUser(); u != nil {
+		return u.ID
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/docs"
+)
+
+// VersionsArgs are the arguments of the versions command.
+var VersionsArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package"},
+	},
+	Flags: []args.Flag{
+		{Name: "page", Kind: args.Int},
+	},
+}
+
+// APIDiffArgs are the arguments of the apidiff command.
+var APIDiffArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package"},
+		{Name: "old"},
+		{Name: "new"},
+	},
+	Flags: []args.Flag{
+		{Name: "page", Kind: args.Int},
+	},
+}
+
+// HandleVersions is the handler for the versions command.
+func HandleVersions(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	st := PagerState{
+		Kind:    "versions",
+		Package: a.Get("package"),
+		Owner:   m.Author.ID,
+	}
+	placeholder := startLoading(s, m, st.Package, "pkg.go.dev")
+	p, err := versionsPager(ctx, st)
+	switch {
+	case errors.Is(err, errNoItems):
+		finishLoading(s, m, placeholder, errResponse("`%s` has no tagged versions", st.Package))
+		return
+	case err != nil:
+		finishLoading(s, m, placeholder, docErrResponse(st.Package, err))
+		return
+	}
+	p.SetPage(a.Int("page", 1))
+	sendPaginator(s, m, placeholder, p, nil)
+}
+
+// versionsPager builds a paginator over the tagged versions of the module of a package.
+// Selecting a version shows the package info at that version.
+func versionsPager(ctx context.Context, st PagerState) (*Paginator, error) {
+	versions, err := docs.Versions(ctx, st.Package)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, errNoItems
+	}
+	return &Paginator{
+		Title: fmt.Sprintf("Versions of %s (%d)", st.Package, len(versions)),
+		URL:   docs.BASE + st.Package + "?tab=versions",
+		Len:   len(versions),
+		Render: func(i int) string {
+			v := versions[i]
+			line := fmt.Sprintf("[%s](%s%s@%s)", v.Version, docs.BASE, st.Package, v.Version)
+			if v.Published != "" {
+				line += " — " + v.Published
+			}
+			return line
+		},
+		Select: func(ctx context.Context, i int) *discordgo.MessageEmbed {
+			return pkgResponse(ctx, st.Package+"@"+versions[i].Version)
+		},
+		PagerState: st,
+	}, nil
+}
+
+// HandleAPIDiff is the handler for the apidiff command.
+//
+// i.e, `.apidiff github.com/bwmarrin/discordgo v0.22.0 v0.23.2`
+func HandleAPIDiff(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	st := PagerState{
+		Kind:    "apidiff",
+		Package: a.Get("package"),
+		Version: a.Get("new"),
+		Compare: a.Get("old"),
+		Owner:   m.Author.ID,
+	}
+	placeholder := startLoading(s, m, st.Package+"@"+st.Version, "pkg.go.dev")
+	ctx, stats := withLookupStats(ctx)
+	p, err := apiDiffPager(ctx, st)
+	var docErr *docs.Error
+	switch {
+	case errors.Is(err, errNoItems):
+		finishLoading(s, m, placeholder, errResponse("The API of `%s` didn't change between %s and %s", st.Package, st.Compare, st.Version))
+		return
+	case errors.As(err, &docErr):
+		finishLoading(s, m, placeholder, docErrResponse(docErr.Pkg, err))
+		return
+	case err != nil:
+		finishLoading(s, m, placeholder, docErrResponse(st.Package, err))
+		return
+	}
+	p.SetPage(a.Int("page", 1))
+	sendPaginator(s, m, placeholder, p, stats)
+}
+
+// apiDiffPager builds a paginator over the API changes of a package between the versions Compare and Version.
+func apiDiffPager(ctx context.Context, st PagerState) (*Paginator, error) {
+	old, err := getDoc(ctx, st.Package+"@"+st.Compare)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := getDoc(ctx, st.Package+"@"+st.Version)
+	if err != nil {
+		return nil, err
+	}
+	diff := docs.Diff(old, doc)
+	if diff.Empty() {
+		return nil, errNoItems
+	}
+	lines := apiDiffLines(diff)
+	return &Paginator{
+		Title: fmt.Sprintf("%s %s → %s: +%d −%d ~%d", st.Package, st.Compare, st.Version,
+			len(diff.Added), len(diff.Removed), len(diff.Changed)),
+		URL:        doc.URL,
+		Len:        len(lines),
+		Render:     func(i int) string { return lines[i] },
+		PerPage:    5,
+		PagerState: st,
+	}, nil
+}
+
+// apiDiffLines formats the changes of an API diff, one line per symbol.
+func apiDiffLines(diff docs.APIDiff) []string {
+	var lines []string
+	for _, c := range diff.Added {
+		lines = append(lines, fmt.Sprintf("➕ %s `%s`", c.Kind, c.Name))
+	}
+	for _, c := range diff.Removed {
+		lines = append(lines, fmt.Sprintf("➖ %s `%s`", c.Kind, c.Name))
+	}
+	for _, c := range diff.Changed {
+		lines = append(lines, fmt.Sprintf("✏️ %s `%s`\n`%s`\n→ `%s`", c.Kind, c.Name, shorten(c.Old, 200), shorten(c.New, 200)))
+	}
+	return lines
+}
+
+// shorten returns s on one line, cut to at most n bytes.
+func shorten(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
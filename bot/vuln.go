@@ -0,0 +1,240 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/mod/semver"
+
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/docs"
+)
+
+// VulnArgs are the arguments of the vuln command.
+var VulnArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package"},
+	},
+	Flags: []args.Flag{
+		{Name: "page", Kind: args.Int},
+	},
+}
+
+// HandleVuln is the handler for the vuln command, it lists the known vulnerabilities affecting
+// a version of a module, the latest version if none is given.
+//
+// i.e, `.vuln golang.org/x/net@v0.7.0` or `.vuln net/http@go1.20.1`
+func HandleVuln(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	pkg, version, _ := strings.Cut(a.Get("package"), "@")
+	st := PagerState{
+		Kind:    "vuln",
+		Package: pkg,
+		Owner:   m.Author.ID,
+	}
+	placeholder := startLoading(s, m, a.Get("package"), "the Go vulnerability database")
+	var err error
+	st.Version, err = vulnVersion(ctx, pkg, version)
+	if err != nil {
+		finishLoading(s, m, placeholder, docErrResponse(pkg, err))
+		return
+	}
+	if _, v := docs.VulnModule(pkg, st.Version); !semver.IsValid(v) {
+		finishLoading(s, m, placeholder, errResponse("`%s` is not a valid version.", st.Version))
+		return
+	}
+	ctx, stats := withLookupStats(ctx)
+	p, err := vulnPager(ctx, st)
+	switch {
+	case errors.Is(err, errNoItems):
+		finishLoading(s, m, placeholder, &discordgo.MessageEmbed{
+			Title:       "No known vulnerabilities",
+			Description: fmt.Sprintf("The Go vulnerability database has no entries affecting `%s@%s`.", pkg, st.Version),
+			URL:         docs.BASE + "vuln/",
+		})
+		return
+	case err != nil:
+		finishLoading(s, m, placeholder, docErrResponse(pkg, err))
+		return
+	}
+	p.SetPage(a.Int("page", 1))
+	sendPaginator(s, m, placeholder, p, stats)
+}
+
+// vulnVersion returns the version to check a package at: version if it is given,
+// otherwise the latest Go release for standard library packages or the latest version of the module.
+func vulnVersion(ctx context.Context, pkg, version string) (string, error) {
+	module, _ := docs.VulnModule(pkg, "")
+	if module == docs.StdlibModule {
+		if version != "" && !strings.HasPrefix(version, "go") && !strings.HasPrefix(version, "v") {
+			version = "go" + version
+		}
+		if version != "" {
+			return version, nil
+		}
+		releases, err := docs.Releases(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, r := range releases {
+			if r.Stable {
+				return r.Version, nil
+			}
+		}
+		return "", &docs.Error{Kind: docs.ErrUnavailable, Pkg: pkg}
+	}
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if version != "" {
+		return version, nil
+	}
+	mod, err := docs.LookupModule(ctx, pkg)
+	if err != nil {
+		return "", err
+	}
+	return mod.Latest, nil
+}
+
+// vulnPager builds a paginator over the vulnerabilities affecting a version of a package.
+// Selecting a vulnerability shows its details.
+func vulnPager(ctx context.Context, st PagerState) (*Paginator, error) {
+	module, version := docs.VulnModule(st.Package, st.Version)
+	if module != docs.StdlibModule {
+		mod, err := docs.LookupModule(ctx, st.Package)
+		if err != nil {
+			return nil, err
+		}
+		module = mod.Path
+	}
+	all, err := docs.Vulns(ctx, module, version)
+	if err != nil {
+		return nil, err
+	}
+	var vulns []*docs.Vuln
+	for _, v := range all {
+		if len(affectedPackages(v, module, st.Package)) > 0 {
+			vulns = append(vulns, v)
+		}
+	}
+	if len(vulns) == 0 {
+		return nil, errNoItems
+	}
+	return &Paginator{
+		Title: fmt.Sprintf("Vulnerabilities in %s@%s (%d)", st.Package, st.Version, len(vulns)),
+		URL:   docs.BASE + "vuln/list?q=" + module,
+		Len:   len(vulns),
+		Render: func(i int) string {
+			v := vulns[i]
+			line := fmt.Sprintf("[%s](%s) — %s", v.ID, v.URL(), shorten(v.Summary, 150))
+			if fixed := fixedVersions(v, module); fixed != "" {
+				line += "\nfixed in " + fixed
+			}
+			var syms []string
+			for _, p := range affectedPackages(v, module, st.Package) {
+				syms = append(syms, affectedSymbols(p))
+			}
+			return line + "\n" + shorten(strings.Join(syms, ", "), 300)
+		},
+		Select: func(ctx context.Context, i int) *discordgo.MessageEmbed {
+			return vulnResponse(vulns[i])
+		},
+		PerPage:    5,
+		PagerState: st,
+	}, nil
+}
+
+// affectedPackages returns the packages of module a vulnerability affects, limited to pkg and
+// the packages below it unless pkg is the module. The whole module is affected if the entry
+// doesn't name its packages.
+func affectedPackages(v *docs.Vuln, module, pkg string) []docs.AffectedPackage {
+	var pkgs []docs.AffectedPackage
+	for _, a := range v.Affected {
+		if a.Package.Name != module {
+			continue
+		}
+		if len(a.EcosystemSpecific.Imports) == 0 {
+			pkgs = append(pkgs, docs.AffectedPackage{Path: module})
+			continue
+		}
+		for _, p := range a.EcosystemSpecific.Imports {
+			if pkg == module || pkg == "std" || p.Path == pkg || strings.HasPrefix(p.Path, pkg+"/") {
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+	return pkgs
+}
+
+// affectedSymbols formats the vulnerable symbols of a package, e.g. `http.Server.Serve`.
+func affectedSymbols(p docs.AffectedPackage) string {
+	if len(p.Symbols) == 0 {
+		return fmt.Sprintf("`%s`", p.Path)
+	}
+	name := p.Path[strings.LastIndexByte(p.Path, '/')+1:]
+	syms := make([]string, len(p.Symbols))
+	for i, sym := range p.Symbols {
+		syms[i] = fmt.Sprintf("`%s.%s`", name, sym)
+	}
+	return strings.Join(syms, ", ")
+}
+
+// fixedVersions returns the versions of module a vulnerability was fixed in, Go versions for the standard library.
+func fixedVersions(v *docs.Vuln, module string) string {
+	var fixed []string
+	for _, a := range v.Affected {
+		if a.Package.Name != module {
+			continue
+		}
+		for _, f := range a.Fixed() {
+			if module == docs.StdlibModule {
+				f = "go" + strings.TrimPrefix(f, "v")
+			}
+			fixed = append(fixed, f)
+		}
+	}
+	return strings.Join(fixed, ", ")
+}
+
+// vulnResponse returns the details of a vulnerability: its description, aliases and the affected modules and symbols.
+func vulnResponse(v *docs.Vuln) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s: %s", v.ID, v.Summary),
+		URL:         v.URL(),
+		Description: v.Details,
+	}
+	if len(embed.Title) > 256 {
+		embed.Title = embed.Title[:250] + "..."
+	}
+	if len(embed.Description) > 2000 {
+		embed.Description = embed.Description[:1900] + "\n*Note this embed has been cut because it is too long*"
+	}
+	if len(v.Aliases) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Aliases", Value: strings.Join(v.Aliases, ", ")})
+	}
+	seen := make(map[string]bool)
+	for _, a := range v.Affected {
+		module := a.Package.Name
+		if seen[module] || len(embed.Fields) == 10 {
+			continue
+		}
+		seen[module] = true
+		value := "not fixed yet"
+		if fixed := fixedVersions(v, module); fixed != "" {
+			value = "fixed in " + fixed
+		}
+		for _, p := range affectedPackages(v, module, module) {
+			value += "\n" + affectedSymbols(p)
+		}
+		if len(value) > 1000 {
+			value = value[:1000] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: module, Value: value})
+	}
+	if !v.Published.IsZero() {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "published " + v.Published.Format("Jan 2, 2006")}
+	}
+	return embed
+}
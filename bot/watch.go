@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/mod/semver"
+
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/docs"
+	"github.com/post04/dr-docso/watch"
+)
+
+// Watches stores the modules channels are subscribed to, it has to be set before the watch commands are used.
+var Watches *watch.Store
+
+const (
+	// watchLookupTimeout bounds the lookups made for one module while checking for releases.
+	watchLookupTimeout = 30 * time.Second
+	// watchDiffLines is how many API changes a release announcement shows.
+	watchDiffLines = 10
+)
+
+// WatchArgs are the arguments of the watch command, the module is optional to list the subscriptions instead.
+var WatchArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "package", Optional: true},
+	},
+}
+
+// UnwatchArgs are the arguments of the watch remove command.
+var UnwatchArgs = &args.Spec{
+	Args: []args.Arg{
+		{Name: "module"},
+	},
+}
+
+// HandleWatch is the handler for the watch command, it subscribes the channel to the releases of a module.
+//
+// i.e, `.watch github.com/bwmarrin/discordgo`
+func HandleWatch(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	if !a.Has("package") {
+		HandleWatchList(ctx, s, m, prefix, a)
+		return
+	}
+	pkg := a.Get("package")
+	placeholder := startLoading(s, m, pkg, "the module proxy")
+	mod, err := docs.LookupModule(ctx, pkg)
+	if err != nil {
+		finishLoading(s, m, placeholder, docErrResponse(pkg, err))
+		return
+	}
+	added, err := Watches.Add(watch.Subscription{
+		Module:    mod.Path,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		AddedBy:   m.Author.ID,
+		Added:     time.Now(),
+	}, mod.Latest)
+	if err != nil {
+		log.Printf("could not save watches: %s", err)
+		finishLoading(s, m, placeholder, errResponse("The subscription could not be saved, please try again later."))
+		return
+	}
+	if !added {
+		finishLoading(s, m, placeholder, errResponse("This channel is already watching `%s`.", mod.Path))
+		return
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Watching %s", mod.Path),
+		URL:         docs.BASE + mod.Path,
+		Description: "New versions will be announced in this channel.",
+	}
+	if mod.Latest != "" {
+		embed.Description += fmt.Sprintf("\nThe latest version is %s.", mod.Latest)
+	}
+	if mod.Path != pkg {
+		embed.Description += fmt.Sprintf("\n*note: `%s` belongs to the module `%s`*", pkg, mod.Path)
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("%swatch remove %s to unsubscribe", prefix, mod.Path),
+	}
+	finishLoading(s, m, placeholder, embed)
+}
+
+// HandleWatchList is the handler for the watch list command, it lists the subscriptions of the channel.
+func HandleWatchList(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	subs := Watches.Channel(m.ChannelID)
+	if len(subs) == 0 {
+		s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+			Title:       "Watched modules",
+			Description: fmt.Sprintf("This channel isn't watching any modules, use `%swatch <module>` to subscribe.", prefix),
+		})
+		return
+	}
+	latest := Watches.Modules()
+	var lines []string
+	for _, sub := range subs {
+		line := fmt.Sprintf("[%s](%s%s)", sub.Module, docs.BASE, sub.Module)
+		if v := latest[sub.Module]; v != "" {
+			line += " — " + v
+		}
+		line += fmt.Sprintf(", added by <@%s>", sub.AddedBy)
+		lines = append(lines, line)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Watched modules (%d)", len(subs)),
+		Description: strings.Join(lines, "\n"),
+	}
+	if len(embed.Description) > 2000 {
+		embed.Description = embed.Description[:1900] + "\n*Note this embed has been cut because it is too long*"
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// HandleWatchRemove is the handler for the watch remove command, it unsubscribes the channel from a module.
+func HandleWatchRemove(ctx context.Context, s Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+	module := a.Get("module")
+	removed, err := Watches.Remove(m.ChannelID, module)
+	switch {
+	case err != nil:
+		log.Printf("could not save watches: %s", err)
+		s.ChannelMessageSendEmbed(m.ChannelID, errResponse("The subscription could not be removed, please try again later."))
+	case !removed:
+		s.ChannelMessageSendEmbed(m.ChannelID, errResponse("This channel isn't watching `%s`, see `%swatch list`.", module, prefix))
+	default:
+		s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("Stopped watching %s", module),
+			Description: "New versions won't be announced in this channel anymore.",
+		})
+	}
+}
+
+// CheckWatches looks for new versions of the watched modules and announces them to the subscribed channels.
+func CheckWatches(s Session, cycle time.Duration) {
+	ticker := time.NewTicker(cycle)
+	for range ticker.C {
+		for module, last := range Watches.Modules() {
+			checkWatch(s, module, last)
+		}
+	}
+}
+
+// checkWatch announces the latest version of a module if it is newer than last.
+func checkWatch(s Session, module, last string) {
+	ctx, cancel := context.WithTimeout(context.Background(), watchLookupTimeout)
+	defer cancel()
+	mod, err := docs.LookupModule(ctx, module)
+	if err != nil {
+		log.Printf("could not check %s for releases: %s", module, err)
+		return
+	}
+	// the latest version of an untagged module is a pseudo-version of its latest commit
+	if !mod.Tagged || semver.Compare(mod.Latest, last) <= 0 {
+		return
+	}
+	embed := releaseResponse(ctx, mod, last)
+	for _, sub := range Watches.Subscribers(module) {
+		if _, err := s.ChannelMessageSendEmbed(sub.ChannelID, embed); err != nil {
+			log.Printf("could not announce %s@%s: %s", module, mod.Latest, err)
+		}
+	}
+	if err := Watches.SetLatest(module, mod.Latest); err != nil {
+		log.Printf("could not save watches: %s", err)
+	}
+}
+
+// releaseResponse returns the announcement of a new version of a module, with the API changes of
+// the package at the module root since the previous version.
+func releaseResponse(ctx context.Context, mod docs.Module, previous string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("%s %s released", mod.Path, mod.Latest),
+		URL:   docs.BASE + mod.Path + "@" + mod.Latest,
+	}
+	if mod.Published != "" {
+		embed.Description = fmt.Sprintf("Published %s", mod.Published)
+	}
+	if previous == "" {
+		return embed
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "previous version: " + previous}
+
+	old, err := getDoc(ctx, mod.Path+"@"+previous)
+	if err != nil {
+		return embed
+	}
+	doc, err := getDoc(ctx, mod.Path+"@"+mod.Latest)
+	if err != nil {
+		return embed
+	}
+	diff := docs.Diff(old, doc)
+	if diff.Empty() {
+		embed.Description += "\nThe API of the package didn't change."
+		return embed
+	}
+	lines := apiDiffLines(diff)
+	summary := fmt.Sprintf("\n**API changes:** +%d −%d ~%d", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	if len(lines) > watchDiffLines {
+		lines = append(lines[:watchDiffLines], fmt.Sprintf("*and %d more*", len(lines)-watchDiffLines))
+	}
+	embed.Description += summary + "\n" + strings.Join(lines, "\n")
+	if len(embed.Description) > 2000 {
+		embed.Description = embed.Description[:1900] + "\n*Note this embed has been cut because it is too long*"
+	}
+	return embed
+}
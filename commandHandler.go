@@ -1,76 +1,296 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
 	"github.com/post04/dr-docso/bot"
 )
 
 // New creates an initialized commandhandler
 func New(prefix string, ignoreBots bool) *CommandHandler {
 	return &CommandHandler{
-		Prefix:     prefix,
-		Commands:   make(map[string]*Command),
-		IgnoreBots: ignoreBots,
+		Prefix:         prefix,
+		Commands:       make(map[string]*Command),
+		IgnoreBots:     ignoreBots,
+		Context:        context.Background(),
+		DefaultTimeout: 30 * time.Second,
 	}
 }
 
-// AddCommand adds a new command to command handler
-func (handler *CommandHandler) AddCommand(name string, help string, description string, commandHandler func(s *discordgo.Session, m *discordgo.MessageCreate, prefix string)) {
-	help = strings.ReplaceAll(help, "{prefix}", handler.Prefix)
-	handler.Commands[name] = &Command{
+// AddCommand adds a new command to command handler and returns it so permissions, aliases and subcommands can be set.
+// Occurrences of {prefix} in help are replaced with the prefix of the guild the help is shown in.
+func (handler *CommandHandler) AddCommand(name string, help string, description string, commandHandler func(ctx context.Context, s bot.Session, m *discordgo.MessageCreate, prefix string, a *args.Args)) *Command {
+	command := &Command{
+		Name:        name,
 		Run:         commandHandler,
 		Help:        help,
 		Description: description,
 	}
+	handler.Commands[name] = command
+	return command
 }
 
-// GenHelp generates the help command output.
+// AddSubcommand adds a subcommand, e.g. `stats` in `.cache stats`, and returns it.
+func (command *Command) AddSubcommand(name string, help string, description string, commandHandler func(ctx context.Context, s bot.Session, m *discordgo.MessageCreate, prefix string, a *args.Args)) *Command {
+	if command.Subcommands == nil {
+		command.Subcommands = make(map[string]*Command)
+	}
+	sub := &Command{
+		Name:        command.Name + " " + name,
+		Run:         commandHandler,
+		Help:        help,
+		Description: description,
+	}
+	command.Subcommands[name] = sub
+	return sub
+}
+
+// lookup returns the command called name or with the alias name.
+func lookup(commands map[string]*Command, name string) *Command {
+	if command, ok := commands[name]; ok {
+		return command
+	}
+	for _, command := range commands {
+		for _, alias := range command.Aliases {
+			if alias == name {
+				return command
+			}
+		}
+	}
+	return nil
+}
+
+// CommandName returns the name of the top-level command called name or with the alias name,
+// and whether there is one.
+func (handler *CommandHandler) CommandName(name string) (string, bool) {
+	command := lookup(handler.Commands, strings.ToLower(name))
+	if command == nil {
+		return "", false
+	}
+	return command.Name, true
+}
+
+// Resolve returns the commands invoked by args, the message content without the prefix.
+// The first returned command is the top-level command, the last is the one to run.
+// It returns nil if args don't start with a known command.
+func (handler *CommandHandler) Resolve(args []string) []*Command {
+	if len(args) == 0 {
+		return nil
+	}
+	command := lookup(handler.Commands, strings.ToLower(args[0]))
+	if command == nil {
+		return nil
+	}
+	chain := []*Command{command}
+	for _, arg := range args[1:] {
+		sub := lookup(command.Subcommands, strings.ToLower(arg))
+		if sub == nil {
+			break
+		}
+		command = sub
+		chain = append(chain, command)
+	}
+	return chain
+}
+
+// GenHelp generates the help command output for the default prefix.
 func (handler *CommandHandler) GenHelp() {
 	handler.TimeStarted = time.Now()
+	handler.HelpCommand = handler.helpEmbed(handler.Prefix)
+}
 
-	var longestCommand int
-	for name := range handler.Commands {
-		if len(name) > longestCommand {
-			longestCommand = len(name)
+// HelpEmbed returns the help command output for the given prefix.
+func (handler *CommandHandler) HelpEmbed(prefix string) *discordgo.MessageEmbed {
+	if prefix == handler.Prefix && handler.HelpCommand != nil {
+		return handler.HelpCommand
+	}
+	return handler.helpEmbed(prefix)
+}
+
+func (handler *CommandHandler) helpEmbed(prefix string) *discordgo.MessageEmbed {
+	type line struct{ label, description string }
+	var (
+		longestLabel int
+		lines        []line
+	)
+	add := func(label, description string) {
+		lines = append(lines, line{label, description})
+		if len(label) > longestLabel {
+			longestLabel = len(label)
+		}
+	}
+	for _, name := range sortedNames(handler.Commands) {
+		command := handler.Commands[name]
+		label := prefix + name
+		if len(command.Aliases) > 0 {
+			label += " (" + strings.Join(command.Aliases, ", ") + ")"
+		}
+		add(label, command.Description)
+		for _, subName := range sortedNames(command.Subcommands) {
+			add(prefix+name+" "+subName, command.Subcommands[subName].Description)
 		}
 	}
 
 	// Build the description
 	embedDesc := strings.Builder{}
 	embedDesc.WriteString("```autoit\n")
-	for name := range handler.Commands {
-		embedDesc.WriteString(handler.Prefix)
-		embedDesc.WriteString(name)
-		embedDesc.WriteString(strings.Repeat(" ", (longestCommand-len(name))+1))
+	for _, l := range lines {
+		embedDesc.WriteString(l.label)
+		embedDesc.WriteString(strings.Repeat(" ", (longestLabel-len(l.label))+1))
 		embedDesc.WriteString("#")
-		embedDesc.WriteString(handler.Commands[name].Description)
+		embedDesc.WriteString(l.description)
 		embedDesc.WriteRune('\n')
 	}
 	embedDesc.WriteString("```")
 
-	handler.HelpCommand = &discordgo.MessageEmbed{
+	return &discordgo.MessageEmbed{
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Use %shelp commandName to get more info about a command", handler.Prefix),
+			Text: fmt.Sprintf("Use %shelp commandName to get more info about a command", prefix),
 		},
 		Title:       "Available commands",
 		Description: embedDesc.String(),
 	}
 }
 
+// commandHelp generates the help of a single command.
+func commandHelp(command *Command, prefix string) *discordgo.MessageEmbed {
+	desc := fmt.Sprintf("Name: %s\n", command.Name)
+	if len(command.Aliases) > 0 {
+		desc += fmt.Sprintf("aliases: %s\n", strings.Join(command.Aliases, ", "))
+	}
+	if command.Args != nil {
+		desc += fmt.Sprintf("usage: `%s`\n", command.Args.Usage(prefix+command.Name))
+	}
+	desc += fmt.Sprintf("example: %s\n"+
+		"description: %s",
+		strings.ReplaceAll(command.Help, "{prefix}", prefix),
+		command.Description)
+	if len(command.Subcommands) > 0 {
+		desc += "\nsubcommands:"
+		for _, name := range sortedNames(command.Subcommands) {
+			sub := command.Subcommands[name]
+			desc += fmt.Sprintf("\n`%s%s` %s", prefix, sub.Name, sub.Description)
+		}
+	}
+	return &discordgo.MessageEmbed{
+		Title:       command.Name + " Command",
+		Description: desc,
+	}
+}
+
+func sortedNames(commands map[string]*Command) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HandleHelp is the handler for the help command.
+func (handler *CommandHandler) HandleHelp(ctx context.Context, session bot.Session, msg *discordgo.MessageCreate, prefix string, a *args.Args) {
+	parts := a.Raw()
+	if len(parts) == 0 {
+		session.ChannelMessageSendEmbed(msg.ChannelID, handler.HelpEmbed(prefix))
+		return
+	}
+	chain := handler.Resolve(parts)
+	if chain == nil {
+		session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+			Title:       "Unknown command",
+			Description: fmt.Sprintf("%q is not a valid command; use %shelp to get available commands.", parts[0], prefix),
+		})
+		return
+	}
+	session.ChannelMessageSendEmbed(msg.ChannelID, commandHelp(chain[len(chain)-1], prefix))
+}
+
+// HandleInfo is the handler for the info command.
+func (handler *CommandHandler) HandleInfo(ctx context.Context, session bot.Session, msg *discordgo.MessageCreate, prefix string, a *args.Args) {
+	session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+		Title:       "dr-docso by post and insomnia",
+		Description: fmt.Sprintf("Library: [DiscordGo](https://github.com/bwmarrin/discordgo)\nUptime: <t:%d:R>\nPrefix: %s\nGithub Repo: [here](https://github.com/post04/dr-docso)\nInvite: [Click me](https://discord.com/oauth2/authorize?client_id=817416218390560798&permissions=3221613648&scope=bot)", handler.TimeStarted.Unix(), prefix),
+	})
+}
+
+// PrefixFor returns the prefix used in a guild.
+func (handler *CommandHandler) PrefixFor(guildID string) string {
+	if guildID != "" {
+		if prefix := handler.Settings.Guild(guildID).Prefix; prefix != "" {
+			return prefix
+		}
+	}
+	return handler.Prefix
+}
+
+// CommandText returns the content of a message without its prefix, and whether the message is a command.
+// Commands can be prefixed with the guild's prefix or a mention of the bot; direct messages don't need a prefix.
+func (handler *CommandHandler) CommandText(session bot.Session, guildID, content string) (string, bool) {
+	if user := session.BotUser(); user != nil {
+		for _, mention := range []string{"<@" + user.ID + ">", "<@!" + user.ID + ">"} {
+			if strings.HasPrefix(content, mention) {
+				return strings.TrimSpace(content[len(mention):]), true
+			}
+		}
+	}
+	prefix := handler.PrefixFor(guildID)
+	if strings.HasPrefix(content, prefix) {
+		return content[len(prefix):], true
+	}
+	return content, guildID == ""
+}
+
 // OnMessage handles onmessage event from discordgo for command handler.
 func (handler *CommandHandler) OnMessage(session *discordgo.Session, msg *discordgo.MessageCreate) {
-	parts := strings.Fields(msg.Content)
+	handler.HandleMessage(bot.Wrap(session), msg)
+}
+
+// HandleMessage is OnMessage for any Session.
+func (handler *CommandHandler) HandleMessage(session bot.Session, msg *discordgo.MessageCreate) {
 	if handler.OnMessageHandler != nil {
 		go handler.OnMessageHandler(session, msg)
 	}
-	if msg.Author.Bot && handler.IgnoreBots {
+	handler.handle(session, msg)
+}
+
+// handle runs the command in msg, if there is one.
+func (handler *CommandHandler) handle(session bot.Session, msg *discordgo.MessageCreate) {
+	if msg.Author == nil || (msg.Author.Bot && handler.IgnoreBots) {
+		return
+	}
+	text, ok := handler.CommandText(session, msg.GuildID, msg.Content)
+	if !ok {
 		return
 	}
-	if len(parts) < 1 || !strings.HasPrefix(parts[0], handler.Prefix) {
+	parts, err := args.Tokenize(text)
+	if err != nil || len(parts) == 0 {
+		return
+	}
+	chain := handler.Resolve(parts)
+	if chain == nil {
+		return
+	}
+	// the prefix shown in help and examples
+	prefix := handler.PrefixFor(msg.GuildID)
+	command := chain[len(chain)-1]
+	name := chain[0].Name
+
+	// the config command is always available so a guild can't lock itself out
+	if guild := handler.Settings.Guild(msg.GuildID); name != "config" &&
+		(!guild.ChannelAllowed(msg.ChannelID) || !guild.CommandEnabled(name)) {
+		return
+	}
+
+	// the rate limit applies before any reply, refusals are answered too
+	if retry := handler.cooldown(msg, command); retry > 0 {
+		handler.slowDown(session, msg, retry)
 		return
 	}
 
@@ -79,56 +299,93 @@ func (handler *CommandHandler) OnMessage(session *discordgo.Session, msg *discor
 			return
 		}
 	}
-	cmd := strings.ToLower(strings.TrimPrefix(parts[0], handler.Prefix))
-
-	switch cmd {
-	case "help":
-		fmt.Println("help command ran by " + msg.Author.Username + "#" + msg.Author.Discriminator + " in " + msg.ChannelID)
-		if len(parts) == 1 {
-			session.ChannelMessageSendEmbed(msg.ChannelID, handler.HelpCommand)
-		} else {
-			embed := &discordgo.MessageEmbed{}
-			if command, ok := handler.Commands[strings.ToLower(parts[1])]; ok {
-				embed.Description = fmt.Sprintf("Name: %s\n"+
-					"example: %s\n"+
-					"description: %s",
-					strings.ToLower(parts[1]),
-					command.Help,
-					command.Description)
-				embed.Title = strings.ToLower(parts[1]) + " Command"
-				session.ChannelMessageSendEmbed(msg.ChannelID, embed)
-			} else {
-				embed.Title = "Unknown command"
-				embed.Description = fmt.Sprintf("%q is not a valid command; use %shelp to get available commands.", parts[1], handler.Prefix)
-				session.ChannelMessageSendEmbed(msg.ChannelID, embed)
-			}
-		}
-	case "info":
-		fmt.Println("info command ran by " + msg.Author.Username + "#" + msg.Author.Discriminator + " in " + msg.ChannelID)
 
-		session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
-			Title:       "dr-docso by post and insomnia",
-			Description: fmt.Sprintf("Library: [DiscordGo](https://github.com/bwmarrin/discordgo)\nUptime: <t:%d:R>\nPrefix: %s\nGithub Repo: [here](https://github.com/post04/dr-docso)\nInvite: [Click me](https://discord.com/oauth2/authorize?client_id=817416218390560798&permissions=3221613648&scope=bot)", handler.TimeStarted.Unix(), handler.Prefix),
-		})
-	default:
-		if command, ok := handler.Commands[cmd]; ok {
-			fmt.Println(parts[0][len(handler.Prefix):] + " command ran by " + msg.Author.Username + "#" + msg.Author.Discriminator + " in " + msg.ChannelID)
-			go command.Run(session, msg, handler.Prefix)
+	fmt.Println(command.Name + " command ran by " + msg.Author.Username + "#" + msg.Author.Discriminator + " in " + msg.ChannelID)
+	for _, c := range chain {
+		reason, err := checkAccess(session, msg, c)
+		if err != nil {
+			log.Printf("could not get permissions: %s", err)
+			session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+				Title:       "Error",
+				Description: "Could not verify your permissions, please try again later.",
+			})
+			return
+		}
+		if reason != "" {
+			session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+				Title:       "Missing permissions",
+				Description: reason,
+			})
+			return
 		}
 	}
+	// commands that only group subcommands show their usage
+	if command.Run == nil {
+		session.ChannelMessageSendEmbed(msg.ChannelID, commandHelp(command, prefix))
+		return
+	}
+	a, err := command.Args.Parse(parts[len(chain):])
+	if err != nil {
+		session.ChannelMessageSendEmbed(msg.ChannelID, usageResponse(command, prefix, err))
+		return
+	}
+	handler.run(command, func(ctx context.Context) {
+		command.Run(ctx, session, msg, prefix, a)
+	})
+}
+
+// run calls fn in a new goroutine with a context bounded by the command's timeout.
+func (handler *CommandHandler) run(command *Command, fn func(ctx context.Context)) {
+	timeout := command.Timeout
+	if timeout == 0 {
+		timeout = handler.DefaultTimeout
+	}
+	handler.running.Add(1)
+	go func() {
+		defer handler.running.Done()
+		ctx, cancel := context.WithTimeout(handler.Context, timeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+// Wait waits for the running commands to return, or for the timeout to pass.
+// It reports whether all commands returned.
+func (handler *CommandHandler) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		handler.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// usageResponse generates the embed for arguments that don't match a command's spec.
+func usageResponse(command *Command, prefix string, err error) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "Usage " + command.Name,
+		Description: fmt.Sprintf("%s\n\nUsage: `%s`\nExample: %s",
+			strings.ToUpper(err.Error()[:1])+err.Error()[1:],
+			command.Args.Usage(prefix+command.Name),
+			strings.ReplaceAll(command.Help, "{prefix}", prefix)),
+	}
 }
 
 // OnEdit handles onedit event from discordgo for command handler.
+// Edited commands are run again if their reply is tracked, the handlers update the reply in place.
 func (handler *CommandHandler) OnEdit(session *discordgo.Session, msg *discordgo.MessageUpdate) {
-	parts := strings.Fields(msg.Content)
-	if len(parts) < 1 || !strings.HasPrefix(parts[0], handler.Prefix) {
+	if msg.Author == nil || !bot.HasReply(msg.ID) {
 		return
 	}
-	cmd := strings.ToLower(strings.TrimPrefix(parts[0], handler.Prefix))
-	if cmd != "docs" {
+	// updates that only add embeds to the message don't change the command
+	if msg.BeforeUpdate != nil && msg.BeforeUpdate.Content == msg.Content {
 		return
 	}
-
-	fmt.Println(parts[0][len(handler.Prefix):] + " command edit by " + msg.Author.Username + "#" + msg.Author.Discriminator + " in " + msg.ChannelID)
-	bot.HandleDocUpdate(session, msg)
+	fmt.Println("command edit by " + msg.Author.Username + "#" + msg.Author.Discriminator + " in " + msg.ChannelID)
+	handler.handle(bot.Wrap(session), &discordgo.MessageCreate{Message: msg.Message})
 }
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/discordtest"
+	"github.com/post04/dr-docso/ratelimit"
+	"github.com/post04/dr-docso/settings"
+)
+
+// testHandler returns a handler with the prefix "!" and a few commands that record their runs.
+func testHandler(t *testing.T) (*CommandHandler, *[]string) {
+	t.Helper()
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	record := func(ctx context.Context, s bot.Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+		mu.Lock()
+		ran = append(ran, strings.TrimSpace(strings.Join(append([]string{prefix}, a.Raw()...), " ")))
+		mu.Unlock()
+	}
+	handler := New("!", true)
+	store, err := settings.Open(t.TempDir() + "/settings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.Settings = store
+	echo := handler.AddCommand("echo", "{prefix}echo hi", "Repeat the arguments", record)
+	echo.Aliases = []string{"e"}
+	echo.Args = &args.Spec{Args: []args.Arg{{Name: "text", Optional: true, Rest: true}}}
+	handler.AddCommand("ping", "{prefix}ping", "Answer", record).
+		Args = &args.Spec{}
+	handler.AddCommand("admin", "{prefix}admin", "Only for admins", record).
+		Permissions = discordgo.PermissionManageServer
+	group := handler.AddCommand("group", "{prefix}group sub", "Group subcommands", nil)
+	group.AddSubcommand("sub", "{prefix}group sub", "A subcommand", record)
+	return handler, &ran
+}
+
+// message returns a message sent by the user "user" in a guild channel.
+func message(content string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "message",
+		ChannelID: "channel",
+		GuildID:   "guild",
+		Content:   content,
+		Author:    &discordgo.User{ID: "user", Username: "gopher", Discriminator: "0001"},
+	}}
+}
+
+func TestHandleMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// edit changes the message before it's handled
+		edit func(m *discordgo.MessageCreate)
+		// perms are the permissions of the author
+		perms int64
+		// permsErr fails the permission lookup
+		permsErr error
+		// disabled are the commands disabled in the guild
+		disabled []string
+		// ran are the runs of the commands, as the prefix followed by the arguments
+		ran []string
+		// reply is the title of the reply sent by the handler, if any
+		reply string
+	}{
+		{name: "command", content: "!echo hello world", ran: []string{"! hello world"}},
+		{name: "alias", content: "!E hello", ran: []string{"! hello"}},
+		{name: "mention", content: "<@bot> echo hi", ran: []string{"! hi"}},
+		{name: "nickname mention", content: "<@!bot> echo hi", ran: []string{"! hi"}},
+		{name: "no prefix", content: "echo hello"},
+		{name: "unknown command", content: "!nothing"},
+		{name: "direct message", content: "echo hi", edit: func(m *discordgo.MessageCreate) { m.GuildID = "" }, ran: []string{"! hi"}},
+		{name: "bot author", content: "!echo hi", edit: func(m *discordgo.MessageCreate) { m.Author.Bot = true }},
+		{name: "missing permissions", content: "!admin", reply: "Missing permissions"},
+		{name: "permissions", content: "!admin", perms: discordgo.PermissionManageServer, ran: []string{"!"}},
+		{name: "permission lookup failure", content: "!admin", permsErr: errors.New("discord is down"), reply: "Error"},
+		{name: "administrator", content: "!admin", perms: discordgo.PermissionAdministrator, ran: []string{"!"}},
+		{name: "disabled", content: "!echo hi", disabled: []string{"echo"}},
+		{name: "subcommand", content: "!group sub", ran: []string{"!"}},
+		{name: "group usage", content: "!group", reply: "group Command"},
+		{name: "usage", content: "!ping extra", reply: "Usage ping"},
+		{name: "unterminated quote", content: `!echo "hi`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, ran := testHandler(t)
+			if len(tt.disabled) > 0 {
+				handler.Settings.Update("guild", func(g *settings.Guild) { g.DisabledCommands = tt.disabled })
+			}
+			s := discordtest.New()
+			s.Permissions["user"] = tt.perms
+			s.PermissionsErr = tt.permsErr
+			msg := message(tt.content)
+			if tt.edit != nil {
+				tt.edit(msg)
+			}
+			handler.HandleMessage(s, msg)
+			if !handler.Wait(time.Second) {
+				t.Fatal("the command didn't return")
+			}
+
+			if strings.Join(*ran, "|") != strings.Join(tt.ran, "|") {
+				t.Errorf("ran %q, want %q", *ran, tt.ran)
+			}
+			var reply string
+			if embed := s.LastEmbed(); embed != nil {
+				reply = embed.Title
+			}
+			if reply != tt.reply {
+				t.Errorf("reply %q, want %q", reply, tt.reply)
+			}
+		})
+	}
+}
+
+func TestHandleMessagePrefix(t *testing.T) {
+	handler, ran := testHandler(t)
+	handler.Settings.Update("guild", func(g *settings.Guild) { g.Prefix = "?" })
+	s := discordtest.New()
+	for _, content := range []string{"!echo default", "?echo guild"} {
+		handler.HandleMessage(s, message(content))
+	}
+	handler.Wait(time.Second)
+	if want := []string{"? guild"}; strings.Join(*ran, "|") != strings.Join(want, "|") {
+		t.Errorf("ran %q, want %q", *ran, want)
+	}
+}
+
+func TestHandleMessageCooldown(t *testing.T) {
+	handler, ran := testHandler(t)
+	handler.SetCooldowns(map[string]Cooldown{
+		"default": {User: ratelimit.Rate{Burst: 1, Per: time.Minute}},
+	})
+	s := discordtest.New()
+	for i := 0; i < 3; i++ {
+		handler.HandleMessage(s, message("!echo hi"))
+	}
+	handler.Wait(time.Second)
+
+	if len(*ran) != 1 {
+		t.Errorf("the command ran %d times, want 1", len(*ran))
+	}
+	// the author is told to slow down once, not for every message
+	sends := s.CallsTo("ChannelMessageSendEmbed")
+	if len(sends) != 1 || sends[0].Embed.Title != "Slow down" {
+		t.Errorf("replies = %+v, want one slow down", sends)
+	}
+	// the cooldowns are per command
+	handler.HandleMessage(s, message("!ping"))
+	handler.Wait(time.Second)
+	if len(*ran) != 2 {
+		t.Errorf("ping didn't run: %q", *ran)
+	}
+}
+
+func TestHandleMessageOnMessageHandler(t *testing.T) {
+	handler, _ := testHandler(t)
+	seen := make(chan string, 2)
+	handler.OnMessageHandler = func(s bot.Session, msg *discordgo.MessageCreate) {
+		seen <- msg.Content
+	}
+	s := discordtest.New()
+	// every message is passed on, commands or not
+	for _, content := range []string{"hello", "!echo hi"} {
+		handler.HandleMessage(s, message(content))
+		select {
+		case got := <-seen:
+			if got != content {
+				t.Errorf("OnMessageHandler got %q, want %q", got, content)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("OnMessageHandler wasn't called for %q", content)
+		}
+	}
+	handler.Wait(time.Second)
+}
+
+func TestCommandName(t *testing.T) {
+	handler, _ := testHandler(t)
+	tests := []struct {
+		name, want string
+		ok         bool
+	}{
+		{"echo", "echo", true},
+		{"E", "echo", true},
+		{"group", "group", true},
+		{"sub", "", false},
+		{"nothing", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := handler.CommandName(tt.name); got != tt.want || ok != tt.ok {
+			t.Errorf("CommandName(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHandleMessageSubcommandCooldown(t *testing.T) {
+	handler, ran := testHandler(t)
+	handler.SetCooldowns(map[string]Cooldown{
+		"group sub": {User: ratelimit.Rate{Burst: 1, Per: time.Minute}},
+	})
+	s := discordtest.New()
+	for i := 0; i < 2; i++ {
+		handler.HandleMessage(s, message("!group sub"))
+	}
+	handler.Wait(time.Second)
+	if len(*ran) != 1 {
+		t.Errorf("the subcommand ran %d times, want 1", len(*ran))
+	}
+	if embed := s.LastEmbed(); embed == nil || embed.Title != "Slow down" {
+		t.Errorf("reply = %+v, want slow down", embed)
+	}
+}
+
+func TestHandleMessageChannelCooldownRefund(t *testing.T) {
+	handler, ran := testHandler(t)
+	handler.SetCooldowns(map[string]Cooldown{
+		"default": {
+			User:    ratelimit.Rate{Burst: 1, Per: time.Minute},
+			Channel: ratelimit.Rate{Burst: 1, Per: time.Minute},
+		},
+	})
+	s := discordtest.New()
+	// someone else uses up the channel's quota
+	other := message("!echo first")
+	other.Author = &discordgo.User{ID: "other"}
+	handler.HandleMessage(s, other)
+	handler.HandleMessage(s, message("!echo refused"))
+	handler.Wait(time.Second)
+
+	// the refusal by the channel limit left the user's token, the user can run the command elsewhere
+	msg := message("!echo elsewhere")
+	msg.ChannelID = "elsewhere"
+	handler.HandleMessage(s, msg)
+	handler.Wait(time.Second)
+	if want := []string{"! first", "! elsewhere"}; strings.Join(*ran, "|") != strings.Join(want, "|") {
+		t.Errorf("ran %q, want %q", *ran, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/ratelimit"
+)
+
+// slowDownLifetime is the minimum time a slow down reply stays in the channel.
+const slowDownLifetime = 5 * time.Second
+
+// Cooldown is the rate limit configuration of a command.
+type Cooldown struct {
+	User    ratelimit.Rate `json:"user"`
+	Channel ratelimit.Rate `json:"channel"`
+}
+
+// SetCooldowns configures the rate limits of the added commands, keyed by their full name,
+// e.g. "watch remove" for a subcommand. Subcommands inherit the limits of their parent, and the
+// "default" entry is used for commands, or limits, that aren't configured.
+func (handler *CommandHandler) SetCooldowns(cooldowns map[string]Cooldown) {
+	def := cooldowns["default"]
+	for _, command := range handler.Commands {
+		setCooldown(command, cooldowns, def)
+	}
+	handler.warnings = ratelimit.NewLimiter(ratelimit.Rate{Burst: 1, Per: 10 * time.Second})
+}
+
+// setCooldown configures the rate limits of command and its subcommands,
+// using inherited for the limits that aren't configured.
+func setCooldown(command *Command, cooldowns map[string]Cooldown, inherited Cooldown) {
+	cd := cooldowns[command.Name]
+	if cd.User.IsZero() {
+		cd.User = inherited.User
+	}
+	if cd.Channel.IsZero() {
+		cd.Channel = inherited.Channel
+	}
+	command.userLimit = ratelimit.NewLimiter(cd.User)
+	command.channelLimit = ratelimit.NewLimiter(cd.Channel)
+	for _, sub := range command.Subcommands {
+		setCooldown(sub, cooldowns, cd)
+	}
+}
+
+// cooldown takes a token for the author and channel of msg.
+// It returns how long they have to wait if the command can't be run now.
+func (handler *CommandHandler) cooldown(msg *discordgo.MessageCreate, command *Command) time.Duration {
+	if ok, retry := command.userLimit.Allow(msg.Author.ID); !ok {
+		return retry
+	}
+	if ok, retry := command.channelLimit.Allow(msg.ChannelID); !ok {
+		// the user didn't get to run the command
+		command.userLimit.Refund(msg.Author.ID)
+		return retry
+	}
+	return 0
+}
+
+// slowDown tells the author of msg to wait, at most once every few seconds per user.
+// The reply deletes itself once the cooldown is over.
+func (handler *CommandHandler) slowDown(session bot.Session, msg *discordgo.MessageCreate, retry time.Duration) {
+	if ok, _ := handler.warnings.Allow(msg.Author.ID); !ok {
+		return
+	}
+	reply, err := session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+		Title:       "Slow down",
+		Description: fmt.Sprintf("<@%s>, you're using commands too quickly, try again in %v seconds.", msg.Author.ID, math.Ceil(retry.Seconds())),
+	})
+	if err != nil {
+		return
+	}
+	if retry < slowDownLifetime {
+		retry = slowDownLifetime
+	}
+	time.AfterFunc(retry, func() {
+		session.ChannelMessageDelete(reply.ChannelID, reply.ID)
+	})
+}
+
+// CleanLimiters periodically forgets rate limit buckets that refilled completely.
+func (handler *CommandHandler) CleanLimiters(GcCycle time.Duration) {
+	garbageCollector := time.NewTicker(GcCycle)
+	for range garbageCollector.C {
+		for _, command := range handler.Commands {
+			cleanLimiters(command)
+		}
+		handler.warnings.Clean()
+	}
+}
+
+// cleanLimiters cleans the rate limits of command and its subcommands.
+func cleanLimiters(command *Command) {
+	command.userLimit.Clean()
+	command.channelLimit.Clean()
+	for _, sub := range command.Subcommands {
+		cleanLimiters(sub)
+	}
+}
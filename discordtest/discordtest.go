@@ -0,0 +1,196 @@
+// Package discordtest provides a fake Discord session that records what the bot does,
+// so command handlers can be run without connecting to Discord.
+package discordtest
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/bot"
+)
+
+// ErrUnknownMessage is returned for messages the session doesn't know about.
+var ErrUnknownMessage = errors.New("unknown message")
+
+// Call is a recorded call to the session.
+type Call struct {
+	Method    string
+	ChannelID string
+	MessageID string
+	Embed     *discordgo.MessageEmbed
+	Emoji     string
+	UserID    string
+}
+
+// Session is a fake bot.Session. Sent messages are kept in memory, so they can
+// be edited, fetched and deleted like on Discord. It is safe for concurrent use.
+type Session struct {
+	// User is the user the bot is logged in as.
+	User *discordgo.User
+	// Permissions are the permissions of users, keyed by user ID.
+	Permissions map[string]int64
+	// Roles are the roles of every guild, keyed by role ID.
+	Roles map[string]*discordgo.Role
+	// PermissionsErr is returned by UserChannelPermissions if it isn't nil.
+	PermissionsErr error
+
+	mu       sync.Mutex
+	calls    []Call
+	messages map[string]*discordgo.Message
+	nextID   int
+}
+
+var _ bot.Session = (*Session)(nil)
+
+// New returns a session logged in as a bot with the ID "bot".
+func New() *Session {
+	return &Session{
+		User:        &discordgo.User{ID: "bot", Username: "dr-docso", Bot: true},
+		Permissions: make(map[string]int64),
+		Roles:       make(map[string]*discordgo.Role),
+		messages:    make(map[string]*discordgo.Message),
+	}
+}
+
+// AddMessage makes a message known to the session, e.g. a message the bot sent before.
+func (s *Session) AddMessage(msg *discordgo.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[msg.ID] = msg
+}
+
+// Message returns the current state of a message, or nil if it doesn't exist (anymore).
+func (s *Session) Message(messageID string) *discordgo.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages[messageID]
+}
+
+// Calls returns the recorded calls in order.
+func (s *Session) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the recorded calls of a method in order.
+func (s *Session) CallsTo(method string) []Call {
+	var calls []Call
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// LastEmbed returns the embed that was sent or edited last, or nil.
+func (s *Session) LastEmbed() *discordgo.MessageEmbed {
+	calls := s.Calls()
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].Embed != nil {
+			return calls[i].Embed
+		}
+	}
+	return nil
+}
+
+// Reset forgets the recorded calls, the messages are kept.
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+func (s *Session) record(c Call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, c)
+}
+
+func (s *Session) ChannelMessage(channelID, messageID string) (*discordgo.Message, error) {
+	s.record(Call{Method: "ChannelMessage", ChannelID: channelID, MessageID: messageID})
+	if msg := s.Message(messageID); msg != nil {
+		return msg, nil
+	}
+	return nil, ErrUnknownMessage
+}
+
+func (s *Session) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	s.mu.Lock()
+	s.nextID++
+	msg := &discordgo.Message{
+		ID:        strconv.Itoa(s.nextID),
+		ChannelID: channelID,
+		Author:    s.User,
+		Embeds:    []*discordgo.MessageEmbed{embed},
+	}
+	s.messages[msg.ID] = msg
+	s.mu.Unlock()
+	s.record(Call{Method: "ChannelMessageSendEmbed", ChannelID: channelID, MessageID: msg.ID, Embed: embed})
+	return msg, nil
+}
+
+func (s *Session) ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	s.record(Call{Method: "ChannelMessageEditEmbed", ChannelID: channelID, MessageID: messageID, Embed: embed})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.messages[messageID]
+	if !ok {
+		return nil, ErrUnknownMessage
+	}
+	msg.Embeds = []*discordgo.MessageEmbed{embed}
+	return msg, nil
+}
+
+func (s *Session) ChannelMessageDelete(channelID, messageID string) error {
+	s.record(Call{Method: "ChannelMessageDelete", ChannelID: channelID, MessageID: messageID})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.messages[messageID]; !ok {
+		return ErrUnknownMessage
+	}
+	delete(s.messages, messageID)
+	return nil
+}
+
+func (s *Session) ChannelTyping(channelID string) error {
+	s.record(Call{Method: "ChannelTyping", ChannelID: channelID})
+	return nil
+}
+
+func (s *Session) MessageReactionAdd(channelID, messageID, emojiID string) error {
+	s.record(Call{Method: "MessageReactionAdd", ChannelID: channelID, MessageID: messageID, Emoji: emojiID, UserID: s.User.ID})
+	return nil
+}
+
+func (s *Session) MessageReactionRemove(channelID, messageID, emojiID, userID string) error {
+	s.record(Call{Method: "MessageReactionRemove", ChannelID: channelID, MessageID: messageID, Emoji: emojiID, UserID: userID})
+	return nil
+}
+
+func (s *Session) UserChannelPermissions(userID, channelID string) (int64, error) {
+	s.record(Call{Method: "UserChannelPermissions", ChannelID: channelID, UserID: userID})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.PermissionsErr != nil {
+		return 0, s.PermissionsErr
+	}
+	return s.Permissions[userID], nil
+}
+
+func (s *Session) BotUser() *discordgo.User {
+	return s.User
+}
+
+func (s *Session) Role(guildID, roleID string) (*discordgo.Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if role, ok := s.Roles[roleID]; ok {
+		return role, nil
+	}
+	return nil, discordgo.ErrStateNotFound
+}
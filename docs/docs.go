@@ -1,23 +1,49 @@
 package docs
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
-	"unicode"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/post04/dr-docso/ratelimit"
 )
 
 const BASE = "https://pkg.go.dev/"
 
+var (
+	// BaseURL is where GetDoc requests pages from. It can point to a server
+	// with saved pages, the URLs in the returned docs always point to BASE.
+	BaseURL = BASE
+	// Client is the HTTP client used to request pages from pkg.go.dev.
+	Client = &http.Client{Timeout: 15 * time.Second}
+	// Limiter limits the requests made to pkg.go.dev, GetDoc waits for it.
+	// A nil Limiter doesn't limit anything.
+	Limiter *ratelimit.Bucket
+)
+
 type Doc struct {
 	URL       string     `json:"url"`
 	Name      string     `json:"name"`
 	Overview  string     `json:"overview"`
 	Types     []Type     `json:"types"`
 	Functions []Function `json:"functions"`
+	// Module is read from the page, CompleteModule adds the details from the module proxy.
+	Module Module `json:"module"`
+	// Subdirectories are the packages in directories below the package.
+	Subdirectories []Subdirectory `json:"subdirectories,omitempty"`
+}
+
+// Subdirectory is a package in a directory below another package.
+type Subdirectory struct {
+	// Path is the full import path.
+	Path     string `json:"path"`
+	Synopsis string `json:"synopsis"`
 }
 
 type Function struct {
@@ -25,9 +51,16 @@ type Function struct {
 	Type      FunctionType `json:"type"`
 	Signature string       `json:"signature"`
 	MethodOf  string       `json:"methodOf"`
+	// Receiver is the receiver type of a method without the pointer, e.g. List[T].
+	Receiver   string      `json:"receiver,omitempty"`
+	TypeParams []TypeParam `json:"typeParams,omitempty"`
 
 	Example  string   `json:"example"`
 	Comments []string `json:"comments"`
+	// Deprecated is the deprecation notice, empty if the function isn't deprecated.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Since is the Go version the function was added in, e.g. go1.21. It is only known for the standard library.
+	Since string `json:"since,omitempty"`
 }
 
 type FunctionType string
@@ -37,18 +70,17 @@ const (
 	FnMethod FunctionType = "method"
 )
 
-var (
-	reType   = regexp.MustCompile(`^type\s([a-zA-Z0-9_]+)\s([a-zA-Z0-9_]+).*`)
-	reFunc   = regexp.MustCompile(`^func\s([a-zA-Z0-9_]+)\(.*\).*$`)
-	reMethod = regexp.MustCompile(`^func\s\(([a-zA-Z0-9\*\s]+)\)\s([a-zA-Z0-9]+).+$`)
-)
-
 type Type struct {
 	Name      string `json:"name"`
 	Type      string `json:"type"`
 	Signature string `json:"signature"`
 
-	Comments []string `json:"comments"`
+	TypeParams []TypeParam `json:"typeParams,omitempty"`
+	Comments   []string    `json:"comments"`
+	// Deprecated is the deprecation notice, empty if the type isn't deprecated.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Since is the Go version the type was added in, e.g. go1.21. It is only known for the standard library.
+	Since string `json:"since,omitempty"`
 }
 
 // FullComment returns the entire comment from Type.Comments, joined with new lines.
@@ -93,16 +125,81 @@ func Synopsis(s string) string {
 }
 
 // GetDoc returns a document representing the specified package/module.
-func GetDoc(pkg string) (*Doc, error) {
-	resp, err := http.Get(BASE + pkg)
+// Failures are reported as an *Error.
+func GetDoc(ctx context.Context, pkg string) (*Doc, error) {
+	page, err := fetchPage(ctx, pkg, "")
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	d, err := parseDoc(pkg, page)
+	if err != nil {
+		return nil, err
+	}
+	if _, version, _ := strings.Cut(pkg, "@"); d.Module.Version == "" {
+		d.Module.setVersion(version)
+	}
+	return d, nil
+}
+
+// fetchPage requests a tab of the pkg.go.dev page of pkg, the documentation if tab is empty.
+// Failures are reported as an *Error.
+func fetchPage(ctx context.Context, pkg, tab string) (*goquery.Document, error) {
+	if err := Limiter.Wait(ctx); err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: pkg, Err: err}
+	}
+	pageURL := BaseURL + pkg
+	if tab != "" {
+		pageURL += "?tab=" + tab
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, &Error{Kind: ErrNotFound, Pkg: pkg, Err: err}
+	}
+	resp, err := Client.Do(req)
 	if err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: pkg, Err: err}
+	}
+	defer resp.Body.Close()
+	if err := statusError(pkg, resp); err != nil {
 		return nil, err
 	}
+	if to := strings.TrimPrefix(resp.Request.URL.Path, basePath()); !strings.EqualFold(to, pkg) {
+		// unknown paths end up on the search page
+		if strings.HasPrefix(to, "search") {
+			return nil, &Error{Kind: ErrNotFound, Pkg: pkg, StatusCode: resp.StatusCode}
+		}
+		return nil, &Error{Kind: ErrRedirected, Pkg: pkg, StatusCode: resp.StatusCode, RedirectedTo: to}
+	}
+	page, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, &Error{Kind: ErrParse, Pkg: pkg, StatusCode: resp.StatusCode, Err: err}
+	}
+	return page, nil
+}
+
+// basePath returns the path of BaseURL, the prefix of the paths of the pages.
+func basePath() string {
+	u, err := url.Parse(BaseURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// Parse reads the documentation of pkg from its pkg.go.dev page.
+// Failures are reported as an *Error.
+func Parse(pkg string, r io.Reader) (*Doc, error) {
+	page, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, &Error{Kind: ErrParse, Pkg: pkg, Err: err}
+	}
+	return parseDoc(pkg, page)
+}
+
+func parseDoc(pkg string, doc *goquery.Document) (*Doc, error) {
+	if doc.Find(".Documentation, .UnitDirectories, .UnitReadme").Length() == 0 {
+		return nil, &Error{Kind: ErrParse, Pkg: pkg}
+	}
 	var (
 		funcs    []Function
 		types    []Type
@@ -120,9 +217,7 @@ func GetDoc(pkg string) (*Doc, error) {
 			Signature: sign,
 			Type:      FnNormal,
 		}
-		if matches := reFunc.FindStringSubmatch(sign); len(matches) == 2 {
-			fn.Name = matches[1]
-		} else {
+		if !fn.parseSignature() || fn.MethodOf != "" {
 			return
 		}
 		fn.Example = item.Find("textarea.Documentation-exampleCode").First().Text()
@@ -132,6 +227,7 @@ func GetDoc(pkg string) (*Doc, error) {
 				fn.Comments = append(fn.Comments, par)
 			}
 		})
+		fn.Deprecated, fn.Since = symbolStatus(item)
 		funcs = append(funcs, fn)
 	})
 
@@ -143,9 +239,7 @@ func GetDoc(pkg string) (*Doc, error) {
 			Signature: sign,
 			Type:      FnNormal,
 		}
-		if matches := reFunc.FindStringSubmatch(sign); len(matches) == 2 {
-			fn.Name = matches[1]
-		} else {
+		if !fn.parseSignature() || fn.MethodOf != "" {
 			return
 		}
 		fn.Example = item.Find("textarea.Documentation-exampleCode").First().Text()
@@ -155,6 +249,7 @@ func GetDoc(pkg string) (*Doc, error) {
 				fn.Comments = append(fn.Comments, par)
 			}
 		})
+		fn.Deprecated, fn.Since = symbolStatus(item)
 		funcs = append(funcs, fn)
 	})
 
@@ -166,10 +261,7 @@ func GetDoc(pkg string) (*Doc, error) {
 			Signature: sign,
 			Type:      FnMethod,
 		}
-		if matches := reMethod.FindStringSubmatch(sign); len(matches) == 3 {
-			fn.MethodOf = extractType(matches[1])
-			fn.Name = matches[2]
-		} else {
+		if !fn.parseSignature() || fn.MethodOf == "" {
 			return
 		}
 
@@ -180,6 +272,7 @@ func GetDoc(pkg string) (*Doc, error) {
 				fn.Comments = append(fn.Comments, par)
 			}
 		})
+		fn.Deprecated, fn.Since = symbolStatus(item)
 		funcs = append(funcs, fn)
 	})
 
@@ -188,10 +281,7 @@ func GetDoc(pkg string) (*Doc, error) {
 		sign = item.Find("pre").First().Text()
 		// sign = strings.ReplaceAll(sign, "\n", "")
 		t := Type{Signature: sign}
-		if matches := reType.FindStringSubmatch(sign); len(matches) == 3 {
-			t.Name = matches[1]
-			t.Type = matches[2]
-		} else {
+		if !t.parseSignature() {
 			return
 		}
 		item.Find("p").Each(func(_ int, p *goquery.Selection) {
@@ -200,6 +290,7 @@ func GetDoc(pkg string) (*Doc, error) {
 				t.Comments = append(t.Comments, par)
 			}
 		})
+		t.Deprecated, t.Since = symbolStatus(item)
 		types = append(types, t)
 	})
 
@@ -212,22 +303,70 @@ func GetDoc(pkg string) (*Doc, error) {
 	})
 
 	return &Doc{
-		URL:       BASE + pkg,
-		Overview:  overview,
-		Name:      pkg,
-		Functions: funcs,
-		Types:     types,
+		URL:            BASE + pkg,
+		Overview:       overview,
+		Name:           pkg,
+		Functions:      funcs,
+		Types:          types,
+		Module:         parseModule(doc),
+		Subdirectories: parseSubdirectories(doc),
 	}, nil
 }
 
-// extractType extracts the type from a method definition
-// i.e, `t *Type` -> `Type`
-func extractType(s string) string {
-	for i := len(s) - 1; i >= 0; i-- {
-		if unicode.IsSpace(rune(s[i])) ||
-			s[i] == '*' {
-			return s[i+1:]
+// symbolSelector matches the documentation blocks of symbols.
+const symbolSelector = ".Documentation-function, .Documentation-type, .Documentation-typeFunc, .Documentation-typeMethod"
+
+// symbolStatus returns the deprecation notice of the symbol documented in item
+// and the Go version it was added in. Blocks of the methods of a type are skipped.
+func symbolStatus(item *goquery.Selection) (deprecated, since string) {
+	header := item.Find("h3, h4").First()
+	since = strings.TrimSpace(header.Find(".Documentation-sinceVersion").Text())
+	since = strings.TrimSpace(strings.TrimPrefix(since, "added in"))
+
+	item.Find("p").EachWithBreak(func(_ int, p *goquery.Selection) bool {
+		if !p.Closest(symbolSelector).IsSelection(item) {
+			return true
+		}
+		text := strings.TrimSpace(p.Text())
+		if strings.HasPrefix(text, "Deprecated:") {
+			deprecated = strings.Join(strings.Fields(strings.TrimPrefix(text, "Deprecated:")), " ")
+			return false
 		}
+		return true
+	})
+	if deprecated == "" && header.Find(".Documentation-deprecatedTag").Length() > 0 {
+		deprecated = "deprecated"
+	}
+	return deprecated, since
+}
+
+var reReplacement = regexp.MustCompile(`(?i)\buse\s+(?:the\s+)?` + "`?" + `([a-zA-Z0-9_./]+[a-zA-Z0-9_])`)
+
+// Replacement returns the symbol or package a deprecation notice suggests instead, or an empty string.
+// i.e, `Use golang.org/x/text/cases instead.` -> `golang.org/x/text/cases`
+func Replacement(deprecated string) string {
+	if m := reReplacement.FindStringSubmatch(deprecated); m != nil {
+		return m[1]
 	}
-	return s
+	return ""
+}
+
+// parseSubdirectories reads the directories table of a package page.
+func parseSubdirectories(doc *goquery.Document) []Subdirectory {
+	var dirs []Subdirectory
+	doc.Find(".UnitDirectories tr").Each(func(_ int, row *goquery.Selection) {
+		href, ok := row.Find(".UnitDirectories-pathCell a").First().Attr("href")
+		if !ok || !strings.HasPrefix(href, "/") {
+			return
+		}
+		path := strings.TrimPrefix(href, "/")
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+		dirs = append(dirs, Subdirectory{
+			Path:     path,
+			Synopsis: strings.Join(strings.Fields(row.Find(".UnitDirectories-desktopSynopsis").Text()), " "),
+		})
+	})
+	return dirs
 }
@@ -0,0 +1,127 @@
+package docs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The fixtures in testdata are refreshed with `go run ./tools/fixtures -update`.
+// The pages checked in are trimmed copies of the pkg.go.dev pages, running the
+// command with network access replaces them with complete ones.
+var fixtures = []string{
+	"strings",
+	"golang.org/x/exp/slices",
+	"net/http",
+}
+
+// servePages serves the saved pages under the paths pkg.go.dev uses, like `go run ./tools/fixtures -serve`.
+func servePages(t *testing.T) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkg := strings.TrimPrefix(r.URL.Path, "/")
+		http.ServeFile(w, r, filepath.Join("testdata", strings.ReplaceAll(pkg, "/", "_")+".html"))
+	})
+}
+
+// useServer points BaseURL at a test server with handler until the test ends.
+func useServer(t *testing.T, handler http.Handler, prefix string) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	old := BaseURL
+	BaseURL = srv.URL + prefix
+	t.Cleanup(func() {
+		BaseURL = old
+		srv.Close()
+	})
+}
+
+func TestGetDocGolden(t *testing.T) {
+	useServer(t, servePages(t), "/")
+	for _, pkg := range fixtures {
+		t.Run(pkg, func(t *testing.T) {
+			doc, err := GetDoc(context.Background(), pkg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(doc, "", "    ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", strings.ReplaceAll(pkg, "/", "_")+".golden.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, bytes.TrimSuffix(want, []byte("\n"))) {
+				t.Errorf("GetDoc(%q) doesn't match the golden file, run `go run ./tools/fixtures` to see what changed:\n%s", pkg, got)
+			}
+		})
+	}
+}
+
+func TestGetDocBaseURLPrefix(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/pkgsite/", http.StripPrefix("/pkgsite", servePages(t)))
+	useServer(t, mux, "/pkgsite/")
+	doc, err := GetDoc(context.Background(), "strings")
+	if err != nil {
+		t.Fatalf("GetDoc with a base URL path: %v", err)
+	}
+	if doc.URL != BASE+"strings" {
+		t.Errorf("URL = %q, want %q", doc.URL, BASE+"strings")
+	}
+}
+
+func TestGetDocErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/strings", servePages(t))
+	mux.Handle("/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>no results</body></html>"))
+	}))
+	mux.Handle("/example.com/unknown", http.RedirectHandler("/search?q=example.com/unknown", http.StatusFound))
+	mux.Handle("/golang.org/x/exp", http.RedirectHandler("/strings", http.StatusFound))
+	mux.Handle("/limited", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	mux.Handle("/broken", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	mux.Handle("/empty", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>nothing here</body></html>"))
+	}))
+	useServer(t, mux, "/")
+
+	tests := []struct {
+		pkg  string
+		kind error
+		// check inspects the error further
+		check func(e *Error) bool
+	}{
+		{"missing", ErrNotFound, nil},
+		{"example.com/unknown", ErrNotFound, nil},
+		{"golang.org/x/exp", ErrRedirected, func(e *Error) bool { return e.RedirectedTo == "strings" }},
+		{"limited", ErrRateLimited, func(e *Error) bool { return e.RetryAfter == 30*time.Second }},
+		{"broken", ErrUnavailable, func(e *Error) bool { return e.StatusCode == http.StatusInternalServerError }},
+		{"empty", ErrParse, nil},
+	}
+	for _, tt := range tests {
+		_, err := GetDoc(context.Background(), tt.pkg)
+		var docErr *Error
+		if !errors.As(err, &docErr) || !errors.Is(err, tt.kind) {
+			t.Errorf("GetDoc(%q) = %v, want %v", tt.pkg, err, tt.kind)
+			continue
+		}
+		if tt.check != nil && !tt.check(docErr) {
+			t.Errorf("GetDoc(%q) = %#v", tt.pkg, docErr)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The kinds of errors returned by GetDoc, use errors.Is to check for them.
+var (
+	// ErrNotFound means pkg.go.dev has no package at the path.
+	ErrNotFound = errors.New("package not found")
+	// ErrRedirected means pkg.go.dev redirected to another page, usually the module root.
+	ErrRedirected = errors.New("redirected")
+	// ErrRateLimited means pkg.go.dev refused the request because too many were made.
+	ErrRateLimited = errors.New("rate limited by pkg.go.dev")
+	// ErrUnavailable means pkg.go.dev could not be reached or failed to serve the page.
+	ErrUnavailable = errors.New("pkg.go.dev is unavailable")
+	// ErrParse means the page could not be understood, pkg.go.dev may have changed its layout.
+	ErrParse = errors.New("could not parse the page")
+)
+
+// Error is the error returned by GetDoc when pkg.go.dev doesn't serve the documentation.
+type Error struct {
+	// Kind is one of the Err* variables.
+	Kind error
+	Pkg  string
+	// StatusCode is the HTTP status of the response, if there was one.
+	StatusCode int
+	// RedirectedTo is the path pkg.go.dev redirected to, set for ErrRedirected.
+	RedirectedTo string
+	// RetryAfter is how long pkg.go.dev asked to wait, set for ErrRateLimited if known.
+	RetryAfter time.Duration
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Pkg, e.Kind)
+	switch {
+	case e.RedirectedTo != "":
+		msg += " to " + e.RedirectedTo
+	case e.Err != nil:
+		msg += ": " + e.Err.Error()
+	case e.StatusCode != 0:
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	return msg
+}
+
+// Is reports whether target is the kind of e.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// statusError returns the error for a non-200 response, or nil.
+func statusError(pkg string, resp *http.Response) error {
+	e := &Error{Pkg: pkg, StatusCode: resp.StatusCode}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		e.Kind = ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		}
+	default:
+		e.Kind = ErrUnavailable
+	}
+	return e
+}
@@ -0,0 +1,60 @@
+package docs
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Imports returns the import paths of the packages imported by pkg.
+// Failures are reported as an *Error.
+func Imports(ctx context.Context, pkg string) ([]string, error) {
+	page, err := fetchPage(ctx, pkg, "imports")
+	if err != nil {
+		return nil, err
+	}
+	return importPaths(page.Find(".Imports-list a")), nil
+}
+
+// ImportedBy returns the known importers of pkg, and how many packages import it in total.
+// Failures are reported as an *Error.
+func ImportedBy(ctx context.Context, pkg string) ([]string, int, error) {
+	page, err := fetchPage(ctx, pkg, "importedby")
+	if err != nil {
+		return nil, 0, err
+	}
+	importers := importPaths(page.Find(".ImportedBy-list a"))
+	total := len(importers)
+	// the header counts all importers, e.g. "Imported by: 2,115"
+	count := page.Find(`[data-test-id="UnitHeader-importedby"]`).First().Text()
+	count = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(count), "Imported by:"))
+	if n, err := strconv.Atoi(strings.ReplaceAll(count, ",", "")); err == nil && n > total {
+		total = n
+	}
+	return importers, total, nil
+}
+
+// importPaths returns the package paths the links point to, without duplicates.
+func importPaths(links *goquery.Selection) []string {
+	var (
+		paths []string
+		seen  = make(map[string]bool)
+	)
+	links.Each(func(_ int, a *goquery.Selection) {
+		href, ok := a.Attr("href")
+		if !ok || !strings.HasPrefix(href, "/") {
+			return
+		}
+		path := strings.TrimPrefix(href, "/")
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	})
+	return paths
+}
@@ -0,0 +1,235 @@
+package docs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/post04/dr-docso/ratelimit"
+)
+
+var (
+	// ProxyURL is the module proxy used to look up module versions and go.mod files.
+	ProxyURL = "https://proxy.golang.org/"
+	// ProxyLimiter limits the requests made to the module proxy.
+	// It isn't pkg.go.dev, so Limiter doesn't apply. A nil ProxyLimiter doesn't limit anything.
+	ProxyLimiter *ratelimit.Bucket
+)
+
+// moduleCacheTTL is how long completed module details are reused, it matches the cache of the docs.
+const moduleCacheTTL = 10 * time.Minute
+
+// Module describes the module a package belongs to.
+// Fields that couldn't be determined are left empty.
+type Module struct {
+	Path string `json:"path"`
+	// Version is the version the documentation was generated for.
+	Version string `json:"version"`
+	Latest  string `json:"latest"`
+	// Published is the date the version was published, as shown on pkg.go.dev.
+	Published string `json:"published"`
+	License   string `json:"license"`
+	// Tagged reports whether Version is a tagged version rather than a pseudo-version.
+	Tagged bool `json:"tagged"`
+	// Stable reports whether Version is a tagged version of at least v1 without a pre-release suffix.
+	Stable bool `json:"stable"`
+	// Redistributable reports whether the license allows pkg.go.dev to show the documentation.
+	Redistributable bool `json:"redistributable"`
+	// GoVersion is the go directive of the module's go.mod file.
+	GoVersion  string `json:"goVersion"`
+	Repository string `json:"repository"`
+}
+
+var (
+	rePseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}(\+incompatible)?$`)
+	reStable        = regexp.MustCompile(`^v[1-9][0-9]*\.[0-9]+\.[0-9]+(\+incompatible)?$`)
+)
+
+// setVersion sets the version and whether it is tagged and stable.
+// Standard library versions are Go releases, e.g. go1.21.0.
+func (m *Module) setVersion(version string) {
+	m.Version = version
+	if strings.HasPrefix(version, "go") {
+		m.Tagged = true
+		m.Stable = !strings.Contains(version, "rc") && !strings.Contains(version, "beta")
+		return
+	}
+	m.Tagged = strings.HasPrefix(version, "v") && !rePseudoVersion.MatchString(version)
+	m.Stable = m.Tagged && reStable.MatchString(version)
+}
+
+// parseModule reads the module details shown in the header of a pkg.go.dev page.
+func parseModule(doc *goquery.Document) Module {
+	var m Module
+	field := func(testID, label string) string {
+		s := strings.TrimSpace(doc.Find(`[data-test-id="` + testID + `"]`).First().Text())
+		return strings.TrimSpace(strings.TrimPrefix(s, label))
+	}
+	m.setVersion(field("UnitHeader-version", "Version:"))
+	m.Published = field("UnitHeader-commitTime", "Published:")
+	m.License = field("UnitHeader-licenses", "License:")
+	m.Repository, _ = doc.Find(".UnitMeta-repo a").First().Attr("href")
+	// the details list checks off "Redistributable license", pages without it
+	// don't show the documentation at all
+	m.Redistributable = doc.Find(".Documentation").Length() > 0
+	doc.Find(".UnitMeta-details li").Each(func(_ int, item *goquery.Selection) {
+		if strings.Contains(item.Text(), "Redistributable license") {
+			alt, _ := item.Find("img").First().Attr("alt")
+			m.Redistributable = alt == "checked"
+		}
+	})
+	return m
+}
+
+// CompleteModule returns m, the module of pkg as read from its page, completed with the module path,
+// latest version and go version from the module proxy. Details that couldn't be looked up are left empty.
+// The details are cached by package and version, like the docs they complete.
+func CompleteModule(ctx context.Context, pkg string, m Module) (Module, error) {
+	path, _, _ := strings.Cut(pkg, "@")
+	key := path + "@" + m.Version
+	moduleCache.Lock()
+	c, ok := moduleCache.entries[key]
+	moduleCache.Unlock()
+	if ok && time.Since(c.fetched) < moduleCacheTTL {
+		return c.module, nil
+	}
+
+	if err := lookupModule(ctx, path, &m); err != nil {
+		return m, err
+	}
+	moduleCache.Lock()
+	defer moduleCache.Unlock()
+	for k, c := range moduleCache.entries {
+		if time.Since(c.fetched) >= moduleCacheTTL {
+			delete(moduleCache.entries, k)
+		}
+	}
+	moduleCache.entries[key] = cachedModule{module: m, fetched: time.Now()}
+	return m, nil
+}
+
+type cachedModule struct {
+	module  Module
+	fetched time.Time
+}
+
+// moduleCache keeps the module details completed by CompleteModule for moduleCacheTTL,
+// keyed by package path and version.
+var moduleCache = struct {
+	sync.Mutex
+	entries map[string]cachedModule
+}{entries: make(map[string]cachedModule)}
+
+// lookupModule completes m with the module path, latest version and go version from the module proxy.
+// Standard library packages belong to the module std, which isn't on the proxy.
+func lookupModule(ctx context.Context, pkg string, m *Module) error {
+	if first, _, _ := strings.Cut(pkg, "/"); !strings.Contains(first, ".") {
+		m.Path = "std"
+		return nil
+	}
+	// the module path is the longest prefix of the package path the proxy knows
+	var latest struct {
+		Version string
+		Time    time.Time
+	}
+	for path := pkg; m.Path == ""; path = parentPath(path) {
+		if path == "." {
+			return fmt.Errorf("no module found for %s", pkg)
+		}
+		err := proxyGet(ctx, path, "@latest", func(resp *http.Response) error {
+			return json.NewDecoder(resp.Body).Decode(&latest)
+		})
+		if err == nil {
+			m.Path = path
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	m.Latest = latest.Version
+	if m.Version == "" {
+		m.setVersion(latest.Version)
+	}
+	// the proxy only dates the latest version
+	if m.Published == "" && m.Version == latest.Version && !latest.Time.IsZero() {
+		m.Published = latest.Time.Format("Jan 2, 2006")
+	}
+
+	return proxyGet(ctx, m.Path, "@v/"+m.Version+".mod", func(resp *http.Response) error {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if f := strings.Fields(scanner.Text()); len(f) == 2 && f[0] == "go" {
+				m.GoVersion = f[1]
+				break
+			}
+		}
+		return scanner.Err()
+	})
+}
+
+// LookupModule returns the module pkg belongs to with its latest version, from the module proxy.
+// Published is the date of the latest version. The standard library isn't on the proxy, it is ErrNotFound.
+// Failures are reported as an *Error.
+func LookupModule(ctx context.Context, pkg string) (Module, error) {
+	var m Module
+	if err := lookupModule(ctx, pkg, &m); err != nil {
+		if ctx.Err() != nil {
+			return m, &Error{Kind: ErrUnavailable, Pkg: pkg, Err: ctx.Err()}
+		}
+		if m.Path == "" {
+			return m, &Error{Kind: ErrNotFound, Pkg: pkg}
+		}
+		// the go.mod file is optional here
+	}
+	if m.Path == "std" {
+		return Module{}, &Error{Kind: ErrNotFound, Pkg: pkg}
+	}
+	return m, nil
+}
+
+// proxyGet requests path+suffix from the module proxy, waiting for ProxyLimiter, and calls read with a successful response.
+func proxyGet(ctx context.Context, path, suffix string, read func(resp *http.Response) error) error {
+	if err := ProxyLimiter.Wait(ctx); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ProxyURL+escapePath(path)+"/"+suffix, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("module proxy: %s", resp.Status)
+	}
+	return read(resp)
+}
+
+// parentPath returns path without its last element, or "." if it has only one.
+func parentPath(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return path[:i]
+	}
+	return "."
+}
+
+// escapePath escapes a module path for the proxy, upper case letters are written as ! and the lower case letter.
+func escapePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
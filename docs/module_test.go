@@ -0,0 +1,112 @@
+package docs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/post04/dr-docso/ratelimit"
+)
+
+// testProxy is a module proxy knowing golang.org/x/exp.
+var testProxy = map[string]string{
+	"/golang.org/x/exp/@latest":                                   `{"Version": "v0.0.0-20230801115018-d63ba01acd4b", "Time": "2023-08-01T11:50:18Z"}`,
+	"/golang.org/x/exp/@v/v0.0.0-20230801115018-d63ba01acd4b.mod": "module golang.org/x/exp\n\ngo 1.20\n",
+}
+
+// useProxy serves testProxy until the test ends and returns the number of requests made to it.
+func useProxy(t *testing.T) *int32 {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, ok := testProxy[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	old := ProxyURL
+	ProxyURL = srv.URL + "/"
+	resetModuleCache()
+	t.Cleanup(func() {
+		ProxyURL = old
+		resetModuleCache()
+		srv.Close()
+	})
+	return &requests
+}
+
+func resetModuleCache() {
+	moduleCache.Lock()
+	moduleCache.entries = make(map[string]cachedModule)
+	moduleCache.Unlock()
+}
+
+func TestCompleteModuleCached(t *testing.T) {
+	requests := useProxy(t)
+	ctx := context.Background()
+
+	m, err := CompleteModule(ctx, "golang.org/x/exp/slices", Module{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Path != "golang.org/x/exp" || m.GoVersion != "1.20" || m.Version != m.Latest {
+		t.Errorf("CompleteModule = %+v", m)
+	}
+	// slices and exp are looked up before the go.mod
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("first lookup made %d requests, want 3", got)
+	}
+
+	again, err := CompleteModule(ctx, "golang.org/x/exp/slices", Module{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != m {
+		t.Errorf("cached CompleteModule = %+v, want %+v", again, m)
+	}
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("cached lookup made %d more requests", got-3)
+	}
+
+	// failed lookups aren't cached
+	if _, err := CompleteModule(ctx, "example.com/missing", Module{}); err == nil {
+		t.Fatal("CompleteModule of an unknown module succeeded")
+	}
+	before := atomic.LoadInt32(requests)
+	CompleteModule(ctx, "example.com/missing", Module{})
+	if atomic.LoadInt32(requests) == before {
+		t.Error("the failed lookup was cached")
+	}
+}
+
+func TestProxyLimiter(t *testing.T) {
+	useProxy(t)
+	oldLimiter, oldProxy := Limiter, ProxyLimiter
+	t.Cleanup(func() { Limiter, ProxyLimiter = oldLimiter, oldProxy })
+
+	// an exhausted pkg.go.dev limiter doesn't hold up the proxy
+	Limiter = ratelimit.NewBucket(ratelimit.Rate{Burst: 1, Per: time.Hour})
+	Limiter.Allow()
+	ProxyLimiter = nil
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := CompleteModule(ctx, "golang.org/x/exp/slices", Module{}); err != nil {
+		t.Fatalf("CompleteModule waited for Limiter: %v", err)
+	}
+
+	// an exhausted proxy limiter does
+	resetModuleCache()
+	ProxyLimiter = ratelimit.NewBucket(ratelimit.Rate{Burst: 1, Per: time.Hour})
+	ProxyLimiter.Allow()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := CompleteModule(ctx, "golang.org/x/exp/slices", Module{}); err == nil {
+		t.Error("CompleteModule didn't wait for ProxyLimiter")
+	}
+}
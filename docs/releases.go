@@ -0,0 +1,71 @@
+package docs
+
+import (
+	"context"
+	"strings"
+)
+
+// ReleasesURL lists the Go toolchain releases, in the format of https://go.dev/dl/?mode=json.
+var ReleasesURL = "https://go.dev/dl/?mode=json"
+
+// Release is a release of the Go toolchain.
+type Release struct {
+	// Version is the name of the release, e.g. go1.21.3.
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// URL returns the release notes of a release: the page of the major
+// release, or its entry in the release history for minor releases.
+func (r Release) URL() string {
+	v := strings.TrimPrefix(r.Version, "go")
+	if strings.Count(v, ".") == 2 && !strings.HasSuffix(v, ".0") {
+		return "https://go.dev/doc/devel/release#" + r.Version
+	}
+	return "https://go.dev/doc/" + MajorRelease(r.Version)
+}
+
+// MajorRelease returns the major release of a Go version, e.g. go1.21 for go1.21.3 and go1.21rc2.
+func MajorRelease(version string) string {
+	v := strings.TrimPrefix(version, "go")
+	if i := strings.IndexAny(v, "rb"); i >= 0 {
+		v = v[:i]
+	}
+	if parts := strings.Split(v, "."); len(parts) > 2 {
+		v = strings.Join(parts[:2], ".")
+	}
+	return "go" + v
+}
+
+// Releases returns the current Go releases from ReleasesURL, newest first.
+// Failures are reported as an *Error.
+func Releases(ctx context.Context) ([]Release, error) {
+	var releases []Release
+	if err := getJSON(ctx, ReleasesURL, &releases); err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: "go", Err: err}
+	}
+	return releases, nil
+}
+
+// goSemver converts a Go version to the semantic version the vulnerability database uses,
+// e.g. go1.21 to v1.21.0 and go1.21rc2 to v1.21.0-rc.2. Other versions are returned as they are.
+func goSemver(version string) string {
+	if !strings.HasPrefix(version, "go") {
+		return version
+	}
+	v := strings.TrimPrefix(version, "go")
+	var pre string
+	for _, tag := range []string{"rc", "beta"} {
+		if i := strings.Index(v, tag); i >= 0 {
+			v, pre = v[:i], "-"+tag+"."+v[i+len(tag):]
+			break
+		}
+	}
+	switch strings.Count(v, ".") {
+	case 0:
+		v += ".0.0"
+	case 1:
+		v += ".0"
+	}
+	return "v" + v + pre
+}
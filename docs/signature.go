@@ -0,0 +1,140 @@
+package docs
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// TypeParam is a type parameter of a generic function or type, e.g. `T any`.
+type TypeParam struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"`
+}
+
+// formatTypeParams formats type parameters like in a declaration, e.g. `[K comparable, V any]`.
+// It returns an empty string if there are none.
+func formatTypeParams(params []TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, p := range params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(p.Name)
+		b.WriteByte(' ')
+		b.WriteString(p.Constraint)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parseDecl parses a single declaration, e.g. a function signature without a body.
+func parseDecl(src string) (ast.Decl, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Decls) == 0 {
+		return nil, errors.New("no declaration")
+	}
+	return f.Decls[0], nil
+}
+
+// typeParams returns the type parameters in a field list, which may be nil.
+func typeParams(list *ast.FieldList) []TypeParam {
+	if list == nil {
+		return nil
+	}
+	var params []TypeParam
+	for _, field := range list.List {
+		constraint := types.ExprString(field.Type)
+		for _, name := range field.Names {
+			params = append(params, TypeParam{Name: name.Name, Constraint: constraint})
+		}
+	}
+	return params
+}
+
+// parseSignature sets the name, receiver and type parameters of fn from its signature.
+// It reports false if the signature isn't a function declaration.
+func (fn *Function) parseSignature() bool {
+	decl, err := parseDecl(fn.Signature)
+	if err != nil {
+		return false
+	}
+	d, ok := decl.(*ast.FuncDecl)
+	if !ok {
+		return false
+	}
+	fn.Name = d.Name.Name
+	fn.TypeParams = typeParams(d.Type.TypeParams)
+	if d.Recv == nil || len(d.Recv.List) != 1 {
+		return true
+	}
+
+	recv := d.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	fn.Receiver = types.ExprString(recv)
+	// the receiver of a method on a generic type lists its type parameters, e.g. List[T]
+	switch r := recv.(type) {
+	case *ast.IndexExpr:
+		recv = r.X
+	case *ast.IndexListExpr:
+		recv = r.X
+	}
+	ident, ok := recv.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	fn.MethodOf = ident.Name
+	return true
+}
+
+// parseSignature sets the name, kind and type parameters of t from its signature.
+// It reports false if the signature isn't a type declaration.
+func (t *Type) parseSignature() bool {
+	decl, err := parseDecl(t.Signature)
+	if err != nil {
+		return false
+	}
+	d, ok := decl.(*ast.GenDecl)
+	if !ok || d.Tok != token.TYPE || len(d.Specs) == 0 {
+		return false
+	}
+	spec := d.Specs[0].(*ast.TypeSpec)
+	t.Name = spec.Name.Name
+	t.TypeParams = typeParams(spec.TypeParams)
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		t.Type = "struct"
+	case *ast.InterfaceType:
+		t.Type = "interface"
+	case *ast.FuncType:
+		t.Type = "func"
+	default:
+		t.Type = types.ExprString(spec.Type)
+	}
+	if spec.Assign.IsValid() {
+		t.Type = "= " + t.Type
+	}
+	return true
+}
+
+// FullName returns the name of the function with its type parameters, e.g. `Map[K comparable, V any]`.
+func (fn Function) FullName() string {
+	return fn.Name + formatTypeParams(fn.TypeParams)
+}
+
+// FullName returns the name of the type with its type parameters, e.g. `Set[T comparable]`.
+func (t Type) FullName() string {
+	return t.Name + formatTypeParams(t.TypeParams)
+}
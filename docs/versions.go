@@ -0,0 +1,147 @@
+package docs
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/mod/semver"
+)
+
+// Version is a tagged version of a module.
+type Version struct {
+	Version string `json:"version"`
+	// Published is the date the version was published, as shown on pkg.go.dev. It may be empty.
+	Published string `json:"published"`
+}
+
+// Versions returns the tagged versions of the module pkg belongs to, newest first.
+// Failures are reported as an *Error.
+func Versions(ctx context.Context, pkg string) ([]Version, error) {
+	page, err := fetchPage(ctx, pkg, "versions")
+	if err != nil {
+		return nil, err
+	}
+	var (
+		versions []Version
+		seen     = make(map[string]bool)
+	)
+	page.Find(".Versions-item").Each(func(_ int, item *goquery.Selection) {
+		v := strings.TrimSpace(item.Find("a.js-versionLink").First().Text())
+		if v == "" || seen[v] {
+			return
+		}
+		seen[v] = true
+		versions = append(versions, Version{
+			Version:   v,
+			Published: strings.TrimSpace(item.Find(".Versions-commitTime").First().Text()),
+		})
+	})
+	if len(versions) > 0 {
+		return versions, nil
+	}
+
+	// the module proxy lists the versions too, but it needs a request per version for the dates
+	var m Module
+	if err := lookupModule(ctx, pkg, &m); err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: pkg, Err: err}
+	}
+	if m.Path == "std" {
+		return nil, &Error{Kind: ErrParse, Pkg: pkg}
+	}
+	var list []string
+	err = proxyGet(ctx, m.Path, "@v/list", func(resp *http.Response) error {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if v := strings.TrimSpace(scanner.Text()); semver.IsValid(v) {
+				list = append(list, v)
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: pkg, Err: err}
+	}
+	semver.Sort(list)
+	for i := len(list) - 1; i >= 0; i-- {
+		versions = append(versions, Version{Version: list[i]})
+	}
+	return versions, nil
+}
+
+// Change is a difference in the API of two versions of a package.
+type Change struct {
+	// Kind is func, method or type.
+	Kind string `json:"kind"`
+	// Name is the name of the symbol, methods are written as Type.Method.
+	Name string `json:"name"`
+	// Old and New are the signatures in the two versions, Old is empty for added symbols and New for removed ones.
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// APIDiff lists the exported symbols that were added, removed or changed between two versions of a package.
+type APIDiff struct {
+	Added   []Change `json:"added"`
+	Removed []Change `json:"removed"`
+	Changed []Change `json:"changed"`
+}
+
+// Empty reports whether the API didn't change.
+func (d APIDiff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed) == 0
+}
+
+// Diff compares the functions, methods and types of two docs of a package.
+// Signatures that only differ in white space are the same.
+func Diff(old, doc *Doc) APIDiff {
+	var (
+		diff    APIDiff
+		oldSyms = symbols(old)
+		before  = make(map[string]Change, len(oldSyms))
+	)
+	for _, c := range oldSyms {
+		before[c.Kind+" "+c.Name] = c
+	}
+	for _, c := range symbols(doc) {
+		key := c.Kind + " " + c.Name
+		was, ok := before[key]
+		delete(before, key)
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, c)
+		case normalizeSignature(was.New) != normalizeSignature(c.New):
+			diff.Changed = append(diff.Changed, Change{Kind: c.Kind, Name: c.Name, Old: was.New, New: c.New})
+		}
+	}
+	// removed symbols are reported in the order of the old doc
+	for _, c := range oldSyms {
+		if _, ok := before[c.Kind+" "+c.Name]; ok {
+			diff.Removed = append(diff.Removed, Change{Kind: c.Kind, Name: c.Name, Old: c.New})
+		}
+	}
+	return diff
+}
+
+// symbols returns the functions, methods and types of a doc as changes with only New set.
+func symbols(doc *Doc) []Change {
+	var syms []Change
+	for _, fn := range doc.Functions {
+		c := Change{Kind: "func", Name: fn.Name, New: fn.Signature}
+		if fn.Type == FnMethod {
+			c.Kind, c.Name = "method", fn.MethodOf+"."+fn.Name
+		}
+		syms = append(syms, c)
+	}
+	for _, t := range doc.Types {
+		syms = append(syms, Change{Kind: "type", Name: t.Name, New: t.Signature})
+	}
+	return syms
+}
+
+// normalizeSignature collapses white space, so formatting changes don't count as API changes.
+func normalizeSignature(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
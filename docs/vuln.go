@@ -0,0 +1,288 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/post04/dr-docso/ratelimit"
+)
+
+var (
+	// VulnURL is the Go vulnerability database, see https://go.dev/security/vuln/database.
+	VulnURL = "https://vuln.go.dev/"
+	// FeedLimiter limits the requests made to the vulnerability database and the Go release feed.
+	// They aren't pkg.go.dev, so Limiter doesn't apply. A nil FeedLimiter doesn't limit anything.
+	FeedLimiter *ratelimit.Bucket
+)
+
+const (
+	// vulnCacheTTL is how long the index and entries of the vulnerability database are reused.
+	vulnCacheTTL = 15 * time.Minute
+	// vulnFetchers is how many entries of the vulnerability database are fetched at once.
+	vulnFetchers = 8
+)
+
+// StdlibModule is the module the vulnerability database files standard library packages under.
+const StdlibModule = "stdlib"
+
+// Vuln is an entry of the vulnerability database in the OSV format.
+type Vuln struct {
+	ID        string     `json:"id"`
+	Published time.Time  `json:"published"`
+	Modified  time.Time  `json:"modified"`
+	Aliases   []string   `json:"aliases"`
+	Summary   string     `json:"summary"`
+	Details   string     `json:"details"`
+	Affected  []Affected `json:"affected"`
+}
+
+// URL returns the page of the vulnerability on pkg.go.dev.
+func (v *Vuln) URL() string {
+	return BASE + "vuln/" + v.ID
+}
+
+// Affected describes the versions and symbols of a module a vulnerability affects.
+type Affected struct {
+	Package struct {
+		// Name is the module path.
+		Name string `json:"name"`
+	} `json:"package"`
+	Ranges            []VersionRange `json:"ranges"`
+	EcosystemSpecific struct {
+		Imports []AffectedPackage `json:"imports"`
+	} `json:"ecosystem_specific"`
+}
+
+// VersionRange lists the versions a vulnerability was introduced and fixed in, in order.
+// The versions are semantic versions without the leading v, 0 is the first version.
+type VersionRange struct {
+	Type   string `json:"type"`
+	Events []struct {
+		Introduced string `json:"introduced,omitempty"`
+		Fixed      string `json:"fixed,omitempty"`
+	} `json:"events"`
+}
+
+// AffectedPackage is a vulnerable package of a module, with the vulnerable symbols if they are known.
+type AffectedPackage struct {
+	Path    string   `json:"path"`
+	Symbols []string `json:"symbols"`
+}
+
+// Affects reports whether the module is vulnerable at version, a semantic version with the leading v.
+func (a Affected) Affects(version string) bool {
+	if len(a.Ranges) == 0 {
+		return true
+	}
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+		// the events are sorted, the last one not after version decides
+		affected := false
+		for _, e := range r.Events {
+			switch {
+			case e.Introduced == "0" || e.Introduced != "" && semver.Compare(version, "v"+e.Introduced) >= 0:
+				affected = true
+			case e.Fixed != "" && semver.Compare(version, "v"+e.Fixed) >= 0:
+				affected = false
+			}
+		}
+		if affected {
+			return true
+		}
+	}
+	return false
+}
+
+// Fixed returns the versions the vulnerability was fixed in, with the leading v.
+func (a Affected) Fixed() []string {
+	var fixed []string
+	for _, r := range a.Ranges {
+		for _, e := range r.Events {
+			if e.Fixed != "" {
+				fixed = append(fixed, "v"+e.Fixed)
+			}
+		}
+	}
+	return fixed
+}
+
+// VulnEntry is an entry of the index of the vulnerability database.
+type VulnEntry struct {
+	ID       string    `json:"id"`
+	Modified time.Time `json:"modified"`
+	Aliases  []string  `json:"aliases"`
+}
+
+// VulnIndex returns every entry of the vulnerability database.
+// Failures are reported as an *Error.
+func VulnIndex(ctx context.Context) ([]VulnEntry, error) {
+	var entries []VulnEntry
+	if err := getJSON(ctx, VulnURL+"index/vulns.json", &entries); err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: "vulndb", Err: err}
+	}
+	return entries, nil
+}
+
+// GetVuln returns an entry of the vulnerability database by its ID, e.g. GO-2022-0969.
+// Failures are reported as an *Error.
+func GetVuln(ctx context.Context, id string) (*Vuln, error) {
+	vulnCache.Lock()
+	c, ok := vulnCache.entries[id]
+	vulnCache.Unlock()
+	if ok && time.Since(c.fetched) < vulnCacheTTL {
+		return c.vuln, nil
+	}
+
+	v := new(Vuln)
+	if err := getJSON(ctx, VulnURL+"ID/"+id+".json", v); err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: id, Err: err}
+	}
+	vulnCache.Lock()
+	vulnCache.entries[id] = cachedVuln{vuln: v, fetched: time.Now()}
+	vulnCache.Unlock()
+	return v, nil
+}
+
+// VulnModule returns the module path and version the vulnerability database uses for a module:
+// standard library packages belong to stdlib and Go versions like go1.21.3 are written as v1.21.3.
+func VulnModule(module, version string) (string, string) {
+	if first, _, _ := strings.Cut(module, "/"); !strings.Contains(first, ".") {
+		module = StdlibModule
+	}
+	return module, goSemver(version)
+}
+
+// moduleVulns is an entry of the module index of the vulnerability database.
+type moduleVulns struct {
+	Path  string `json:"path"`
+	Vulns []struct {
+		ID string `json:"id"`
+		// Fixed is the latest version a vulnerability was fixed in.
+		Fixed string `json:"fixed"`
+	} `json:"vulns"`
+}
+
+type cachedVuln struct {
+	vuln    *Vuln
+	fetched time.Time
+}
+
+// vulnCache keeps the module index and the entries of the vulnerability database for vulnCacheTTL.
+var vulnCache = struct {
+	sync.Mutex
+	modules []moduleVulns
+	indexed time.Time
+	entries map[string]cachedVuln
+}{entries: make(map[string]cachedVuln)}
+
+// vulnModules returns the module index of the vulnerability database.
+func vulnModules(ctx context.Context) ([]moduleVulns, error) {
+	vulnCache.Lock()
+	if vulnCache.modules != nil && time.Since(vulnCache.indexed) < vulnCacheTTL {
+		defer vulnCache.Unlock()
+		return vulnCache.modules, nil
+	}
+	vulnCache.Unlock()
+
+	var modules []moduleVulns
+	if err := getJSON(ctx, VulnURL+"index/modules.json", &modules); err != nil {
+		return nil, err
+	}
+	vulnCache.Lock()
+	defer vulnCache.Unlock()
+	vulnCache.modules, vulnCache.indexed = modules, time.Now()
+	for id, c := range vulnCache.entries {
+		if time.Since(c.fetched) > vulnCacheTTL {
+			delete(vulnCache.entries, id)
+		}
+	}
+	return modules, nil
+}
+
+// Vulns returns the vulnerabilities of a module that affect version, sorted by ID.
+// The module and version are in the form VulnModule returns. Failures are reported as an *Error.
+func Vulns(ctx context.Context, module, version string) ([]*Vuln, error) {
+	index, err := vulnModules(ctx)
+	if err != nil {
+		return nil, &Error{Kind: ErrUnavailable, Pkg: module, Err: err}
+	}
+	var ids []string
+	for _, m := range index {
+		if m.Path != module {
+			continue
+		}
+		for _, entry := range m.Vulns {
+			// the index has the latest fixed version, later versions are safe
+			if entry.Fixed == "" || semver.Compare(version, "v"+entry.Fixed) < 0 {
+				ids = append(ids, entry.ID)
+			}
+		}
+	}
+
+	var (
+		vulns    []*Vuln
+		firstErr error
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		fetchers = make(chan struct{}, vulnFetchers)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			fetchers <- struct{}{}
+			v, err := GetVuln(ctx, id)
+			<-fetchers
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for _, a := range v.Affected {
+				if a.Package.Name == module && a.Affects(version) {
+					vulns = append(vulns, v)
+					return
+				}
+			}
+		}(id)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(vulns, func(i, j int) bool { return vulns[i].ID < vulns[j].ID })
+	return vulns, nil
+}
+
+// getJSON requests url, waiting for FeedLimiter, and decodes the JSON response into v.
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	if err := FeedLimiter.Wait(ctx); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
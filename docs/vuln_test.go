@@ -0,0 +1,141 @@
+package docs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testVulnDB is a vulnerability database with two entries for stdlib.
+var testVulnDB = map[string]string{
+	"/index/modules.json": `[
+		{"path": "stdlib", "vulns": [
+			{"id": "GO-2023-0001", "fixed": "1.20.1"},
+			{"id": "GO-2023-0002", "fixed": "1.21.1"}
+		]},
+		{"path": "golang.org/x/net", "vulns": [{"id": "GO-2023-0003"}]}
+	]`,
+	"/ID/GO-2023-0001.json": `{
+		"id": "GO-2023-0001", "summary": "Panic in net/http",
+		"affected": [{
+			"package": {"name": "stdlib"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.19.6"}, {"introduced": "1.20.0-0"}, {"fixed": "1.20.1"}]}],
+			"ecosystem_specific": {"imports": [{"path": "net/http", "symbols": ["Server.Serve"]}]}
+		}]
+	}`,
+	"/ID/GO-2023-0002.json": `{
+		"id": "GO-2023-0002", "summary": "Bad decoding in encoding/xml",
+		"affected": [{
+			"package": {"name": "stdlib"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "1.21.0-0"}, {"fixed": "1.21.1"}]}],
+			"ecosystem_specific": {"imports": [{"path": "encoding/xml", "symbols": ["Decoder.Token"]}]}
+		}]
+	}`,
+	"/ID/GO-2023-0003.json": `{
+		"id": "GO-2023-0003", "summary": "Unfixed in x/net",
+		"affected": [{"package": {"name": "golang.org/x/net"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]}]
+	}`,
+}
+
+// useVulnDB serves testVulnDB until the test ends and returns the number of requests made to it.
+func useVulnDB(t *testing.T) *int32 {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, ok := testVulnDB[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	old := VulnURL
+	VulnURL = srv.URL + "/"
+	resetVulnCache()
+	t.Cleanup(func() {
+		VulnURL = old
+		resetVulnCache()
+		srv.Close()
+	})
+	return &requests
+}
+
+func resetVulnCache() {
+	vulnCache.Lock()
+	vulnCache.modules = nil
+	vulnCache.entries = make(map[string]cachedVuln)
+	vulnCache.Unlock()
+}
+
+func TestVulns(t *testing.T) {
+	requests := useVulnDB(t)
+	tests := []struct {
+		module, version string
+		want            []string
+	}{
+		{"net/http", "go1.19.5", []string{"GO-2023-0001"}},
+		{"net/http", "go1.19.6", nil},
+		{"net/http", "go1.20", []string{"GO-2023-0001"}},
+		{"encoding/xml", "go1.21.0", []string{"GO-2023-0002"}},
+		{"net/http", "go1.21.1", nil},
+		{"golang.org/x/net", "v0.17.0", []string{"GO-2023-0003"}},
+		{"example.com/safe", "v1.0.0", nil},
+	}
+	for _, tt := range tests {
+		module, version := VulnModule(tt.module, tt.version)
+		vulns, err := Vulns(context.Background(), module, version)
+		if err != nil {
+			t.Fatalf("Vulns(%q, %q): %v", module, version, err)
+		}
+		var got []string
+		for _, v := range vulns {
+			got = append(got, v.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Vulns(%q, %q) = %v, want %v", module, version, got, tt.want)
+		}
+	}
+	// the index and every entry are only fetched once
+	if n := atomic.LoadInt32(requests); n != 4 {
+		t.Errorf("%d requests to the vulnerability database, want 4", n)
+	}
+}
+
+func TestVulnCacheExpires(t *testing.T) {
+	requests := useVulnDB(t)
+	if _, err := GetVuln(context.Background(), "GO-2023-0001"); err != nil {
+		t.Fatal(err)
+	}
+	vulnCache.Lock()
+	c := vulnCache.entries["GO-2023-0001"]
+	c.fetched = time.Now().Add(-vulnCacheTTL - time.Second)
+	vulnCache.entries["GO-2023-0001"] = c
+	vulnCache.Unlock()
+	if _, err := GetVuln(context.Background(), "GO-2023-0001"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("%d requests to the vulnerability database, want 2", n)
+	}
+}
+
+func TestGoSemver(t *testing.T) {
+	tests := map[string]string{
+		"go1.21.3":   "v1.21.3",
+		"go1.21":     "v1.21.0",
+		"go1":        "v1.0.0",
+		"go1.21rc2":  "v1.21.0-rc.2",
+		"go1.8beta1": "v1.8.0-beta.1",
+		"v0.7.0":     "v0.7.0",
+	}
+	for in, want := range tests {
+		if got := goSemver(in); got != want {
+			t.Errorf("goSemver(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,25 +1,213 @@
+// Package glob implements the small pattern language used to match symbol names.
+//
+// The supported syntax is:
+//
+//	pattern  matches
+//	*        any run of identifier characters
+//	?        exactly one identifier character
+//	[abc]    one character from the class; ranges like [a-z] are allowed
+//	[!abc]   one character not in the class ([^abc] works too)
+//	{a,b}    either of the comma separated alternatives, which may contain globs
+//	\x       the literal character x
+//	!pat     a leading ! negates the whole pattern
+//	re:expr  expr is used as a raw regular expression
+//
+// Every other character matches itself, ignoring case. Character classes are
+// case sensitive so they can narrow a match, e.g. [A-Z]* only matches exported
+// names. Raw regular expressions are case sensitive too unless they start with (?i).
+//
+// Patterns compiled with CompilePath match import paths instead, where * and ?
+// also match the path characters / . - ~ and +.
 package glob
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
 
-var replacer = strings.NewReplacer(
-	"*", "[a-zA-Z0-9_]*",
-	"?", "[a-zA-Z0-9_]?",
+const (
+	// identChar is the class of characters matched by * and ?.
+	identChar = "[a-zA-Z0-9_]"
+	// pathChar is the class of characters matched by * and ? in path patterns.
+	pathChar = "[a-zA-Z0-9_./~+-]"
+	// RawPrefix marks a pattern as a raw regular expression.
+	RawPrefix = "re:"
 )
 
-func Compile(s string) (*regexp.Regexp, error) {
-	s = replacer.Replace(s)
-	s = "(?i)^" + s + "$"
-	return regexp.Compile(s)
+// Matcher is a compiled glob pattern.
+type Matcher struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool
 }
 
-func MustCompile(s string) *regexp.Regexp {
-	r, err := Compile(s)
+// Compile parses a glob pattern and returns a Matcher for it.
+func Compile(s string) (*Matcher, error) {
+	return compile(s, identChar)
+}
+
+// CompilePath is like Compile, but the pattern matches import paths,
+// e.g. crypto* matches crypto/tls and golang.org/x/* matches golang.org/x/net/html.
+func CompilePath(s string) (*Matcher, error) {
+	return compile(s, pathChar)
+}
+
+// compile compiles a pattern in which * and ? match the characters of class.
+func compile(s, class string) (*Matcher, error) {
+	m := &Matcher{pattern: s}
+	if strings.HasPrefix(s, RawPrefix) {
+		re, err := regexp.Compile(s[len(RawPrefix):])
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+		return m, nil
+	}
+
+	if strings.HasPrefix(s, "!") {
+		m.negate = true
+		s = s[1:]
+	}
+	expr, err := translate(s, class)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile("(?i)^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+	m.re = re
+	return m, nil
+}
+
+// MustCompile is like Compile but panics if the pattern is invalid.
+func MustCompile(s string) *Matcher {
+	m, err := Compile(s)
 	if err != nil {
 		panic(err)
 	}
-	return r
+	return m
+}
+
+// MatchString reports whether s matches the pattern.
+func (m *Matcher) MatchString(s string) bool {
+	return m.re.MatchString(s) != m.negate
+}
+
+// String returns the source pattern.
+func (m *Matcher) String() string {
+	return m.pattern
+}
+
+// IsPattern reports whether s uses any glob syntax, as opposed to being a plain name.
+func IsPattern(s string) bool {
+	return strings.HasPrefix(s, RawPrefix) ||
+		strings.HasPrefix(s, "!") ||
+		strings.ContainsAny(s, `*?[{\`)
+}
+
+// translate converts a glob into an unanchored regular expression,
+// * and ? match the characters of class.
+func translate(s, class string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*':
+			b.WriteString(class + "*")
+		case '?':
+			b.WriteString(class)
+		case '\\':
+			if i+1 == len(s) {
+				return "", fmt.Errorf("trailing backslash in %q", s)
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		case '[':
+			end, set, err := translateClass(s, i)
+			if err != nil {
+				return "", err
+			}
+			// classes keep their case inside the case insensitive pattern
+			b.WriteString("(?-i:" + set + ")")
+			i = end
+		case '{':
+			end, alt, err := translateAlternation(s, i, class)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(alt)
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		}
+	}
+	return b.String(), nil
+}
+
+// translateClass translates the character class starting at s[start].
+// It returns the index of the closing bracket.
+func translateClass(s string, start int) (int, string, error) {
+	var b strings.Builder
+	b.WriteByte('[')
+	i := start + 1
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		b.WriteByte('^')
+		i++
+	}
+	first := i
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ']' && i > first:
+			b.WriteByte(']')
+			return i, b.String(), nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		case c == '-' && i > first && i+1 < len(s) && s[i+1] != ']':
+			b.WriteByte('-')
+		case c == '[' || c == ']' || c == '^' || c == '-' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated character class in %q", s)
+}
+
+// translateAlternation translates the {a,b} group starting at s[start].
+// It returns the index of the closing brace.
+func translateAlternation(s string, start int, class string) (int, string, error) {
+	var (
+		alts  []string
+		depth int
+		last  = start + 1
+	)
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case ',', '}':
+			if depth > 0 {
+				if s[i] == '}' {
+					depth--
+				}
+				continue
+			}
+			alt, err := translate(s[last:i], class)
+			if err != nil {
+				return 0, "", err
+			}
+			alts = append(alts, alt)
+			last = i + 1
+			if s[i] == '}' {
+				return i, "(?:" + strings.Join(alts, "|") + ")", nil
+			}
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated alternation in %q", s)
 }
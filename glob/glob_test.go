@@ -0,0 +1,138 @@
+package glob
+
+import "testing"
+
+func TestMatchString(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{"New*", []string{"New", "NewReader", "newreader", "New_2"}, []string{"ReadNew", "New.Reader", "New-1"}},
+		{"*Reader", []string{"Reader", "NewReader"}, []string{"ReaderAt", "bufio.Reader"}},
+		// ? is exactly one character, not zero or one
+		{"Read?", []string{"Reads", "Read1"}, []string{"Read", "ReadAt"}},
+		{"??", []string{"Go", "io"}, []string{"a", "abc", ".."}},
+		{"[abc]x", []string{"ax", "aX", "cx"}, []string{"dx", "x", "abx", "Bx"}},
+		{"[a-c]1", []string{"a1", "b1"}, []string{"d1", "-1", "C1"}},
+		// classes are case sensitive, the rest of the pattern isn't
+		{"[A-Z]*", []string{"Builder", "URL", "X"}, []string{"builder", "_x", ""}},
+		{"[!A-Z]*", []string{"builder", "_x"}, []string{"Builder"}},
+		{"new[A-Z]*", []string{"NewReader", "newReader"}, []string{"Newreader", "new"}},
+		{"[!abc]x", []string{"dx", "zx", "1x"}, []string{"ax", "bx", "x"}},
+		{"[^abc]x", []string{"dx"}, []string{"ax"}},
+		{"[]]", []string{"]"}, []string{"a"}},
+		{"[a-]", []string{"a", "-"}, []string{"b"}},
+		{"{Read,Writ}er", []string{"Reader", "Writer"}, []string{"Closer", "er"}},
+		{"{Read{er,At},Write}", []string{"Reader", "ReadAt", "Write"}, []string{"Read", "Writer"}},
+		{"{New*,*Func}", []string{"NewReader", "HandlerFunc"}, []string{"Handler"}},
+		{"{a\\,b,c}", []string{"a,b", "c"}, []string{"a", "b"}},
+		{`\*`, []string{"*"}, []string{"a", ""}},
+		{`a\?`, []string{"a?"}, []string{"ab"}},
+		{`\[x]`, []string{"[x]"}, []string{"x"}},
+		{"!New*", []string{"Reader", "ReadNew"}, []string{"New", "NewReader"}},
+		{"re:^Read(er|At)$", []string{"Reader", "ReadAt"}, []string{"ReadAll", "readat"}},
+		{"re:(?i)^read(er|at)$", []string{"Reader", "readat"}, []string{"ReadAll"}},
+		{"re:Write", []string{"Write", "WriteString", "BufWriter"}, []string{"Read"}},
+		// characters with a meaning in regular expressions match themselves
+		{"a.b", []string{"a.b"}, []string{"axb"}},
+		{"a+(b)|c$", []string{"a+(b)|c$"}, []string{"aab"}},
+		{"Reader", []string{"Reader", "reader"}, []string{"Readers"}},
+	}
+	for _, tt := range tests {
+		m, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.pattern, err)
+			continue
+		}
+		for _, s := range tt.match {
+			if !m.MatchString(s) {
+				t.Errorf("%q doesn't match %q", tt.pattern, s)
+			}
+		}
+		for _, s := range tt.noMatch {
+			if m.MatchString(s) {
+				t.Errorf("%q matches %q", tt.pattern, s)
+			}
+		}
+	}
+}
+
+func TestCompilePath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{"crypto*", []string{"crypto", "crypto/tls", "crypto/x509/pkix"}, []string{"golang.org/x/crypto"}},
+		{"golang.org/x/*", []string{"golang.org/x/net/html", "golang.org/x/mod"}, []string{"golang.org/y/net"}},
+		{"*/go-*", []string{"github.com/go-redis/redis", "gopkg.in/go-yaml.v3"}, []string{"github.com/golang/go"}},
+		{"net/http?", []string{"net/http2"}, []string{"net/http", "net/http/httptest"}},
+		{"!{net,crypto}/*", []string{"strings", "net"}, []string{"net/http", "crypto/tls"}},
+	}
+	for _, tt := range tests {
+		m, err := CompilePath(tt.pattern)
+		if err != nil {
+			t.Errorf("CompilePath(%q): %v", tt.pattern, err)
+			continue
+		}
+		for _, s := range tt.match {
+			if !m.MatchString(s) {
+				t.Errorf("%q doesn't match %q", tt.pattern, s)
+			}
+		}
+		for _, s := range tt.noMatch {
+			if m.MatchString(s) {
+				t.Errorf("%q matches %q", tt.pattern, s)
+			}
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"[abc",
+		"[!",
+		"{a,b",
+		"{a,{b,c}",
+		`abc\`,
+		`{a,b\}`,
+		"re:(",
+		"re:a[",
+	} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", pattern)
+		}
+	}
+}
+
+func TestIsPattern(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"Reader", false},
+		{"bufio.Reader", false},
+		{"New*", true},
+		{"Read?", true},
+		{"[ab]", true},
+		{"{a,b}", true},
+		{`a\b`, true},
+		{"!Reader", true},
+		{"re:Reader", true},
+	}
+	for _, tt := range tests {
+		if got := IsPattern(tt.s); got != tt.want {
+			t.Errorf("IsPattern(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMustCompilePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile of an invalid pattern didn't panic")
+		}
+	}()
+	MustCompile("[a")
+}
@@ -1,8 +1,17 @@
 module github.com/post04/dr-docso
 
-go 1.16
+go 1.18
 
 require (
 	github.com/PuerkitoBio/goquery v1.6.1
 	github.com/bwmarrin/discordgo v0.23.2
+	golang.org/x/mod v0.10.0
+)
+
+require (
+	github.com/andybalholm/cascadia v1.1.0 // indirect
+	github.com/gorilla/websocket v1.4.0 // indirect
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2 // indirect
+	golang.org/x/net v0.0.0-20200202094626-16171245cfb2 // indirect
+	golang.org/x/sys v0.0.0-20190215142949-d0b11bdaac8a // indirect
 )
@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
 	cmd "github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/docs"
+	"github.com/post04/dr-docso/ratelimit"
+	"github.com/post04/dr-docso/settings"
+	"github.com/post04/dr-docso/watch"
 )
 
 var c Config
@@ -31,17 +38,94 @@ func getConfig() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if c.Database == "" {
+		c.Database = "guilds.json"
+	}
+	if c.PagerDatabase == "" {
+		c.PagerDatabase = "pagers.json"
+	}
+	if c.WatchDatabase == "" {
+		c.WatchDatabase = "watches.json"
+	}
+	if c.WatchInterval == "" {
+		c.WatchInterval = "30m"
+	}
+	if c.Announcements.Interval == "" {
+		c.Announcements.Interval = "1h"
+	}
+	if c.Announcements.Database == "" {
+		c.Announcements.Database = "announced.json"
+	}
+	if c.OutboundRate.IsZero() {
+		c.OutboundRate = ratelimit.Rate{Burst: 5, Per: time.Second}
+	}
+	if c.ProxyRate.IsZero() {
+		c.ProxyRate = ratelimit.Rate{Burst: 10, Per: time.Second}
+	}
+	if c.FeedRate.IsZero() {
+		c.FeedRate = ratelimit.Rate{Burst: 10, Per: time.Second}
+	}
+	if c.CommandTimeout == "" {
+		c.CommandTimeout = "30s"
+	}
+	if c.RequestTimeout == "" {
+		c.RequestTimeout = "15s"
+	}
 }
 
 func main() {
 	getConfig()
-	cmd.DocsHelpEmbed.Description = fmt.Sprintf(`__**Examples:**__
-%sdocs strings
-%sdocs strings equalfold
-%sdocs strings builder
-%sdocs strings builder.*
-%sdocs strings *.writestring`,
-		c.Prefix, c.Prefix, c.Prefix, c.Prefix, c.Prefix)
+	store, err := settings.Open(c.Database)
+	if err != nil {
+		log.Fatal("ERROR LOADING SETTINGS", err)
+	}
+	cmd.Settings = store
+	cmd.PagerDatabase = c.PagerDatabase
+	if err := cmd.LoadPagers(); err != nil {
+		log.Fatal("ERROR LOADING PAGERS", err)
+	}
+	watches, err := watch.Open(c.WatchDatabase)
+	if err != nil {
+		log.Fatal("ERROR LOADING WATCHES", err)
+	}
+	cmd.Watches = watches
+	watchInterval, err := time.ParseDuration(c.WatchInterval)
+	if err != nil {
+		log.Fatal("INVALID watchInterval", err)
+	}
+	cmd.AnnounceChannels = c.Announcements.Channels
+	cmd.AnnounceDatabase = c.Announcements.Database
+	if err := cmd.LoadAnnouncements(); err != nil {
+		log.Fatal("ERROR LOADING ANNOUNCEMENTS", err)
+	}
+	announceInterval, err := time.ParseDuration(c.Announcements.Interval)
+	if err != nil {
+		log.Fatal("INVALID announcements.interval", err)
+	}
+	cmd.SafeMode = c.SafeMode
+	docs.Limiter = ratelimit.NewBucket(c.OutboundRate)
+	docs.ProxyLimiter = ratelimit.NewBucket(c.ProxyRate)
+	docs.FeedLimiter = ratelimit.NewBucket(c.FeedRate)
+	requestTimeout, err := time.ParseDuration(c.RequestTimeout)
+	if err != nil {
+		log.Fatal("INVALID requestTimeout", err)
+	}
+	docs.Client = &http.Client{Timeout: requestTimeout}
+	if c.DocsBaseURL != "" {
+		docs.BaseURL = c.DocsBaseURL
+	}
+	if c.Announcements.ReleasesURL != "" {
+		docs.ReleasesURL = c.Announcements.ReleasesURL
+	}
+	if c.Announcements.VulnURL != "" {
+		docs.VulnURL = c.Announcements.VulnURL
+	}
+	commandTimeout, err := time.ParseDuration(c.CommandTimeout)
+	if err != nil {
+		log.Fatal("INVALID commandTimeout", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
 	bot, err := discordgo.New("Bot " + c.Token)
 	if err != nil {
 		log.Fatal("ERROR LOGGING IN", err)
@@ -50,19 +134,83 @@ func main() {
 	bot.AddHandler(cmd.ReactionListen)
 
 	cmdhandler := New(c.Prefix, true)
-	cmdhandler.AddCommand("docs", "{prefix}docs github.com/bwmarrin/discordgo", "Get the documentation of a package from pkg.go.dev", cmd.HandleDocSend)
-	cmdhandler.AddCommand("funcs", "{prefix}funcs github.com/bwmarrin/discordgo", "Get all the functions in a package from pkg.go.dev", cmd.HandleFuncsPages)
-	cmdhandler.AddCommand("types", "{prefix}types github.com/bwmarrin/discordgo", "Get all the types in a package from pkg.go.dev", cmd.HandleTypesPages)
-	cmdhandler.AddCommand("info", "{prefix}info", "shows information about dr-docso", nil)
+	cmdhandler.Settings = store
+	cmdhandler.Context = ctx
+	cmdhandler.DefaultTimeout = commandTimeout
+	cmdhandler.Middleware = configMiddleware(cmdhandler, c)
+	docsCommand := cmdhandler.AddCommand("docs", "{prefix}docs github.com/bwmarrin/discordgo", "Get the documentation of a package from pkg.go.dev", cmd.HandleDocSend)
+	docsCommand.Aliases = []string{"d", "doc"}
+	docsCommand.Args = cmd.DocsArgs
+	funcsCommand := cmdhandler.AddCommand("funcs", "{prefix}funcs github.com/bwmarrin/discordgo", "Get all the functions in a package from pkg.go.dev", cmd.HandleFuncsPages)
+	funcsCommand.Aliases = []string{"fn", "functions"}
+	funcsCommand.Args = cmd.PagesArgs
+	typesCommand := cmdhandler.AddCommand("types", "{prefix}types github.com/bwmarrin/discordgo", "Get all the types in a package from pkg.go.dev", cmd.HandleTypesPages)
+	typesCommand.Aliases = []string{"t"}
+	typesCommand.Args = cmd.PagesArgs
+	importsCommand := cmdhandler.AddCommand("imports", "{prefix}imports net/http", "List the packages a package imports", cmd.HandleImports)
+	importsCommand.Args = cmd.ImportsArgs
+	importedByCommand := cmdhandler.AddCommand("importedby", "{prefix}importedby github.com/bwmarrin/discordgo", "List the packages that import a package", cmd.HandleImportedBy)
+	importedByCommand.Args = cmd.ImportsArgs
+	dirCommand := cmdhandler.AddCommand("dir", "{prefix}dir crypto/*", "List the packages in the subdirectories of a package", cmd.HandleDir)
+	dirCommand.Args = cmd.DirArgs
+	versionsCommand := cmdhandler.AddCommand("versions", "{prefix}versions github.com/bwmarrin/discordgo", "List the tagged versions of a module", cmd.HandleVersions)
+	versionsCommand.Args = cmd.VersionsArgs
+	apiDiffCommand := cmdhandler.AddCommand("apidiff", "{prefix}apidiff github.com/bwmarrin/discordgo v0.22.0 v0.23.2", "Show the API changes of a package between two versions", cmd.HandleAPIDiff)
+	apiDiffCommand.Args = cmd.APIDiffArgs
+	vulnCommand := cmdhandler.AddCommand("vuln", "{prefix}vuln golang.org/x/net@v0.7.0", "List the known vulnerabilities affecting a version of a module", cmd.HandleVuln)
+	vulnCommand.Args = cmd.VulnArgs
+	watchCommand := cmdhandler.AddCommand("watch", "{prefix}watch github.com/bwmarrin/discordgo", "Announce new releases of a module in this channel", cmd.HandleWatch)
+	watchCommand.Permissions = discordgo.PermissionManageChannels
+	watchCommand.Args = cmd.WatchArgs
+	watchCommand.AddSubcommand("list", "{prefix}watch list", "List the modules watched in this channel", cmd.HandleWatchList)
+	watchCommand.AddSubcommand("remove", "{prefix}watch remove github.com/bwmarrin/discordgo", "Stop announcing releases of a module in this channel", cmd.HandleWatchRemove).
+		Args = cmd.UnwatchArgs
+	autolinkCommand := cmdhandler.AddCommand("autolink", "{prefix}autolink on", "Answer references like [strings.Builder] in this channel", cmd.HandleAutolink)
+	autolinkCommand.Permissions = discordgo.PermissionManageChannels
+	autolinkCommand.Args = cmd.AutolinkArgs
+	configCommand := cmdhandler.AddCommand("config", "{prefix}config prefix !", "Change the configuration for this server (admin only)", cmd.HandleConfig)
+	configCommand.Permissions = discordgo.PermissionManageServer
+	configCommand.Args = cmd.ConfigArgs
+	cache := cmdhandler.AddCommand("cache", "{prefix}cache stats", "Inspect or flush the documentation cache (admin only)", nil)
+	cache.AddSubcommand("stats", "{prefix}cache stats", "Show how many packages are cached", cmd.HandleCacheStats).
+		Permissions = discordgo.PermissionManageServer
+	cache.AddSubcommand("flush", "{prefix}cache flush", "Drop every cached package", cmd.HandleCacheFlush).
+		Permissions = discordgo.PermissionAdministrator
+	helpCommand := cmdhandler.AddCommand("help", "{prefix}help docs", "shows the available commands", cmdhandler.HandleHelp)
+	helpCommand.Aliases = []string{"h"}
+	helpCommand.Args = &args.Spec{Args: []args.Arg{{Name: "command", Optional: true, Rest: true}}}
+	cmdhandler.AddCommand("info", "{prefix}info", "shows information about dr-docso", cmdhandler.HandleInfo)
+	cmd.CommandName = cmdhandler.CommandName
+	locked := lockedChannels(c)
+	cmdhandler.OnMessageHandler = func(session cmd.Session, msg *discordgo.MessageCreate) {
+		// auto-links are answered before the middleware runs, they check the locked channels themselves
+		if locked[msg.ChannelID] {
+			return
+		}
+		cmd.AutolinkListen(ctx, session, msg)
+	}
+	cmdhandler.SetCooldowns(c.Cooldowns)
+	go cmdhandler.CleanLimiters(5 * time.Minute)
 	cmdhandler.GenHelp()
 	bot.AddHandler(cmdhandler.OnMessage)
 	bot.AddHandler(cmdhandler.OnEdit)
+	bot.AddHandler(cmd.MessageDeleteListen)
 	err = bot.Open()
 	if err != nil {
 		log.Fatal("ERROR OPENING CONNECTION", err)
 	}
+	go cmd.CheckWatches(cmd.Wrap(bot), watchInterval)
+	if len(cmd.AnnounceChannels) > 0 {
+		go cmd.CheckAnnouncements(cmd.Wrap(bot), announceInterval)
+	}
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGTERM)
 	<-sc
+	// stop outstanding lookups and give the commands a moment to answer
+	cancel()
+	if !cmdhandler.Wait(5 * time.Second) {
+		log.Println("some commands were still running at shutdown")
+	}
+	cmd.SavePagers()
 	bot.Close()
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/bot"
+)
+
+// lockedChannels returns the channels config.json locks.
+func lockedChannels(c Config) map[string]bool {
+	locked := make(map[string]bool, len(c.LockedChannels))
+	for _, id := range c.LockedChannels {
+		locked[id] = true
+	}
+	return locked
+}
+
+// configMiddleware returns a middleware enforcing the locked channels and safe mode from config.json.
+func configMiddleware(handler *CommandHandler, c Config) func(session bot.Session, msg *discordgo.MessageCreate) bool {
+	locked := lockedChannels(c)
+	return func(session bot.Session, msg *discordgo.MessageCreate) bool {
+		if locked[msg.ChannelID] {
+			if !c.QuietLock {
+				session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+					Title:       "Channel locked",
+					Description: "Commands can't be used in this channel.",
+				})
+			}
+			return false
+		}
+		if !c.SafeMode {
+			return true
+		}
+
+		// every command fetching a package calls its argument "package"
+		text, _ := handler.CommandText(session, msg.GuildID, msg.Content)
+		parts, err := args.Tokenize(text)
+		if err != nil {
+			return true
+		}
+		chain := handler.Resolve(parts)
+		if chain == nil {
+			return true
+		}
+		command := chain[len(chain)-1]
+		a, err := command.Args.Parse(parts[len(chain):])
+		if err != nil || a.Get("package") == "" {
+			return true
+		}
+		pkg := a.Get("package")
+		// `.docs strings.Builder` is a package and a query in one argument
+		if command.Name == "docs" && !a.Has("query") && !bot.IsStdlib(pkg) {
+			pkg = trimSymbol(pkg)
+		}
+		if command.Name == "dir" {
+			pkg, _ = bot.SplitDirPattern(pkg)
+		}
+		if bot.IsStdlib(pkg) {
+			return true
+		}
+		session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+			Title:       "Safe mode",
+			Description: fmt.Sprintf("`%s` is not a standard library package; only the standard library is available on this bot.", pkg),
+		})
+		return false
+	}
+}
+
+// trimSymbol removes the symbol from a reference like golang.org/x/exp/slices.Sort.
+// The symbol starts at the first dot after the last slash, dots before it belong to the path.
+func trimSymbol(ref string) string {
+	slash := strings.LastIndexByte(ref, '/')
+	if dot := strings.IndexByte(ref[slash+1:], '.'); dot >= 0 {
+		return ref[:slash+1+dot]
+	}
+	return ref
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/discordtest"
+	"github.com/post04/dr-docso/ratelimit"
+)
+
+// middlewareHandler returns testHandler with docs and dir commands, using the middleware for c.
+func middlewareHandler(t *testing.T, c Config) (*CommandHandler, *[]string) {
+	t.Helper()
+	handler, ran := testHandler(t)
+	record := func(ctx context.Context, s bot.Session, m *discordgo.MessageCreate, prefix string, a *args.Args) {
+		handler.Commands["echo"].Run(ctx, s, m, prefix, a)
+	}
+	handler.AddCommand("docs", "{prefix}docs strings", "Documentation", record).Args = bot.DocsArgs
+	handler.AddCommand("dir", "{prefix}dir crypto/*", "Subdirectories", record).Args = bot.DirArgs
+	handler.Middleware = configMiddleware(handler, c)
+	return handler, ran
+}
+
+func TestConfigMiddleware(t *testing.T) {
+	locked := Config{LockedChannels: []string{"locked"}}
+	quiet := Config{LockedChannels: []string{"locked"}, QuietLock: true}
+	safe := Config{SafeMode: true}
+	tests := []struct {
+		name    string
+		config  Config
+		channel string
+		content string
+		// ran reports whether the command ran
+		ran bool
+		// reply is the title of the middleware's reply, if any
+		reply string
+	}{
+		{"locked", locked, "locked", "!echo hi", false, "Channel locked"},
+		{"quiet lock", quiet, "locked", "!echo hi", false, ""},
+		{"other channel", locked, "channel", "!echo hi", true, ""},
+		{"not a command", locked, "locked", "hello", false, ""},
+		{"stdlib", safe, "channel", "!docs strings", true, ""},
+		{"stdlib symbol", safe, "channel", "!docs strings.Builder", true, ""},
+		{"stdlib query", safe, "channel", "!docs strings builder", true, ""},
+		{"stdlib version", safe, "channel", "!docs strings --version go1.21.0", true, ""},
+		{"module", safe, "channel", "!docs github.com/bwmarrin/discordgo", false, "Safe mode"},
+		{"module query", safe, "channel", "!docs github.com/bwmarrin/discordgo Session", false, "Safe mode"},
+		{"stdlib dir", safe, "channel", "!dir crypto/*", true, ""},
+		{"module dir", safe, "channel", "!dir golang.org/x/*", false, "Safe mode"},
+		{"without a package", safe, "channel", "!echo hi", true, ""},
+		{"docs help", safe, "channel", "!docs", true, ""},
+		{"safe mode off", Config{}, "channel", "!docs github.com/bwmarrin/discordgo", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, ran := middlewareHandler(t, tt.config)
+			s := discordtest.New()
+			msg := message(tt.content)
+			msg.ChannelID = tt.channel
+			handler.HandleMessage(s, msg)
+			handler.Wait(time.Second)
+
+			if got := len(*ran) > 0; got != tt.ran {
+				t.Errorf("ran = %v, want %v", got, tt.ran)
+			}
+			var reply string
+			if embed := s.LastEmbed(); embed != nil {
+				reply = embed.Title
+			}
+			if reply != tt.reply {
+				t.Errorf("reply %q, want %q", reply, tt.reply)
+			}
+			for _, c := range s.Calls() {
+				if c.ChannelID != tt.channel {
+					t.Errorf("%s in channel %q, want %q", c.Method, c.ChannelID, tt.channel)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigMiddlewareSafeModeMessage(t *testing.T) {
+	handler, _ := middlewareHandler(t, Config{SafeMode: true})
+	s := discordtest.New()
+	tests := map[string]string{
+		"!docs golang.org/x/exp/slices.Sort":          "golang.org/x/exp/slices",
+		"!docs github.com/bwmarrin/discordgo":         "github.com/bwmarrin/discordgo",
+		"!docs github.com/bwmarrin/discordgo.Session": "github.com/bwmarrin/discordgo",
+	}
+	for content, pkg := range tests {
+		s.Reset()
+		handler.HandleMessage(s, message(content))
+		handler.Wait(time.Second)
+		// the package is named without the symbol
+		if embed := s.LastEmbed(); embed == nil || !strings.Contains(embed.Description, "`"+pkg+"`") {
+			t.Errorf("%s: reply = %+v, want it to name %s", content, embed, pkg)
+		}
+	}
+}
+
+func TestConfigMiddlewareCooldown(t *testing.T) {
+	handler, ran := middlewareHandler(t, Config{LockedChannels: []string{"locked"}, SafeMode: true})
+	handler.SetCooldowns(map[string]Cooldown{
+		"default": {User: ratelimit.Rate{Burst: 1, Per: time.Minute}},
+	})
+	s := discordtest.New()
+	for _, content := range []string{"!echo hi", "!echo hi", "!echo hi"} {
+		msg := message(content)
+		msg.ChannelID = "locked"
+		handler.HandleMessage(s, msg)
+	}
+	for _, content := range []string{"!docs example.com/a", "!docs example.com/b", "!docs example.com/c"} {
+		handler.HandleMessage(s, message(content))
+	}
+	handler.Wait(time.Second)
+
+	if len(*ran) != 0 {
+		t.Errorf("ran %q, want nothing", *ran)
+	}
+	// refusals are rate limited like commands, only the first message of each command is answered
+	// and the author is told to slow down once
+	var titles []string
+	for _, c := range s.CallsTo("ChannelMessageSendEmbed") {
+		titles = append(titles, c.Embed.Title)
+	}
+	want := []string{"Channel locked", "Slow down", "Safe mode"}
+	if strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("replies %q, want %q", titles, want)
+	}
+}
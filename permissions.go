@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/bot"
+)
+
+// permissionNames are the display names of the permissions commands may require.
+var permissionNames = []struct {
+	perm int64
+	name string
+}{
+	{discordgo.PermissionAdministrator, "Administrator"},
+	{discordgo.PermissionManageServer, "Manage Server"},
+	{discordgo.PermissionManageChannels, "Manage Channels"},
+	{discordgo.PermissionManageRoles, "Manage Roles"},
+	{discordgo.PermissionManageMessages, "Manage Messages"},
+	{discordgo.PermissionManageWebhooks, "Manage Webhooks"},
+	{discordgo.PermissionKickMembers, "Kick Members"},
+	{discordgo.PermissionBanMembers, "Ban Members"},
+}
+
+// checkAccess reports why the author of msg may not run command, or an empty string if they may.
+// It returns an error if the permissions of the author could not be looked up.
+func checkAccess(session bot.Session, msg *discordgo.MessageCreate, command *Command) (string, error) {
+	if command.Permissions == 0 && len(command.Roles) == 0 {
+		return "", nil
+	}
+	if msg.GuildID == "" {
+		return "This command is only available in servers.", nil
+	}
+
+	perms, err := session.UserChannelPermissions(msg.Author.ID, msg.ChannelID)
+	if err != nil {
+		return "", err
+	}
+	// administrators can run everything
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return "", nil
+	}
+	if missing := command.Permissions &^ perms; missing != 0 {
+		return fmt.Sprintf("You need the following permissions to use this command: %s", describePermissions(missing)), nil
+	}
+
+	if len(command.Roles) > 0 && !hasRole(session, msg, command.Roles) {
+		return fmt.Sprintf("You need one of the following roles to use this command: %s", strings.Join(command.Roles, ", ")), nil
+	}
+	return "", nil
+}
+
+// hasRole reports whether the author of msg has one of the roles, given by ID or name.
+func hasRole(session bot.Session, msg *discordgo.MessageCreate, roles []string) bool {
+	if msg.Member == nil {
+		return false
+	}
+	for _, roleID := range msg.Member.Roles {
+		name := ""
+		if role, err := session.Role(msg.GuildID, roleID); err == nil {
+			name = role.Name
+		}
+		for _, want := range roles {
+			if want == roleID || (name != "" && strings.EqualFold(want, name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describePermissions returns the names of the permissions set in perms.
+func describePermissions(perms int64) string {
+	var names []string
+	for _, p := range permissionNames {
+		if perms&p.perm != 0 {
+			names = append(names, "`"+p.name+"`")
+			perms &^= p.perm
+		}
+	}
+	if perms != 0 {
+		names = append(names, fmt.Sprintf("`%#x`", perms))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,204 @@
+// Package ratelimit implements token bucket rate limiting.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate allows Burst events at once, refilled evenly over Per.
+// The zero Rate is unlimited.
+type Rate struct {
+	Burst int
+	Per   time.Duration
+}
+
+// ParseRate parses rates written as "burst/duration", e.g. "3/10s".
+func ParseRate(s string) (Rate, error) {
+	i := strings.IndexByte(s, '/')
+	if i < 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: expected burst/duration", s)
+	}
+	burst, err := strconv.Atoi(s[:i])
+	if err != nil || burst < 1 {
+		return Rate{}, fmt.Errorf("invalid rate %q: burst must be a positive integer", s)
+	}
+	per, err := time.ParseDuration(s[i+1:])
+	if err != nil || per <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: bad duration", s)
+	}
+	return Rate{Burst: burst, Per: per}, nil
+}
+
+// IsZero reports whether r is unlimited.
+func (r Rate) IsZero() bool {
+	return r.Burst == 0 || r.Per == 0
+}
+
+func (r Rate) String() string {
+	if r.IsZero() {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d/%s", r.Burst, r.Per)
+}
+
+// UnmarshalJSON reads a rate in the format accepted by ParseRate.
+func (r *Rate) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*r = Rate{}
+		return nil
+	}
+	rate, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// Bucket is a single token bucket. It is safe for concurrent use.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket returns a full bucket for the rate.
+func NewBucket(r Rate) *Bucket {
+	return &Bucket{
+		rate:   r,
+		tokens: float64(r.Burst),
+		last:   time.Now(),
+	}
+}
+
+// refill adds the tokens earned since the last call. The caller must hold b.mu.
+func (b *Bucket) refill(now time.Time) {
+	b.tokens += float64(b.rate.Burst) * float64(now.Sub(b.last)) / float64(b.rate.Per)
+	if b.tokens > float64(b.rate.Burst) {
+		b.tokens = float64(b.rate.Burst)
+	}
+	b.last = now
+}
+
+// Allow takes a token if one is available.
+// Otherwise it reports how long it takes until the next token is available.
+func (b *Bucket) Allow() (bool, time.Duration) {
+	if b == nil || b.rate.IsZero() {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) * float64(b.rate.Per) / float64(b.rate.Burst))
+}
+
+// Refund gives back a token taken by Allow, e.g. when another limit refused the event.
+func (b *Bucket) Refund() {
+	if b == nil || b.rate.IsZero() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens++
+	if b.tokens > float64(b.rate.Burst) {
+		b.tokens = float64(b.rate.Burst)
+	}
+}
+
+// Wait blocks until a token is available and takes it.
+// It returns the context's error if the context is done first.
+func (b *Bucket) Wait(ctx context.Context) error {
+	for {
+		ok, retry := b.Allow()
+		if ok {
+			return nil
+		}
+		timer := time.NewTimer(retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// full reports whether the bucket has refilled completely.
+func (b *Bucket) full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	return b.tokens >= float64(b.rate.Burst)
+}
+
+// Limiter keeps a bucket per key, e.g. per user or per channel.
+// It is safe for concurrent use.
+type Limiter struct {
+	mu      sync.Mutex
+	rate    Rate
+	buckets map[string]*Bucket
+}
+
+// NewLimiter returns a limiter giving every key the rate r.
+func NewLimiter(r Rate) *Limiter {
+	return &Limiter{
+		rate:    r,
+		buckets: make(map[string]*Bucket),
+	}
+}
+
+// Allow takes a token from the bucket of key, see Bucket.Allow.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.rate.IsZero() {
+		return true, 0
+	}
+	l.mu.Lock()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewBucket(l.rate)
+		l.buckets[key] = b
+	}
+	l.mu.Unlock()
+	return b.Allow()
+}
+
+// Refund gives back a token taken from the bucket of key, see Bucket.Refund.
+func (l *Limiter) Refund(key string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	b := l.buckets[key]
+	l.mu.Unlock()
+	b.Refund()
+}
+
+// Clean forgets the buckets that are full again, they behave the same as new ones.
+func (l *Limiter) Clean() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, b := range l.buckets {
+		if b.full() {
+			delete(l.buckets, key)
+		}
+	}
+}
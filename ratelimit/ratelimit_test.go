@@ -0,0 +1,204 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rate
+		err  bool
+	}{
+		{"3/10s", Rate{Burst: 3, Per: 10 * time.Second}, false},
+		{"1/1m30s", Rate{Burst: 1, Per: 90 * time.Second}, false},
+		{"5/1s", Rate{Burst: 5, Per: time.Second}, false},
+		{"3", Rate{}, true},
+		{"0/1s", Rate{}, true},
+		{"-1/1s", Rate{}, true},
+		{"x/1s", Rate{}, true},
+		{"3/", Rate{}, true},
+		{"3/soon", Rate{}, true},
+		{"3/-1s", Rate{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseRate(%q) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestRateJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rate
+		err  bool
+	}{
+		{`"2/20s"`, Rate{Burst: 2, Per: 20 * time.Second}, false},
+		{`""`, Rate{}, false},
+		{`"2"`, Rate{}, true},
+		{`2`, Rate{}, true},
+	}
+	for _, tt := range tests {
+		var got Rate
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestRateString(t *testing.T) {
+	tests := map[Rate]string{
+		{}:                                "unlimited",
+		{Burst: 3}:                        "unlimited",
+		{Burst: 3, Per: 10 * time.Second}: "3/10s",
+	}
+	for r, want := range tests {
+		if got := r.String(); got != want {
+			t.Errorf("%#v.String() = %q, want %q", r, got, want)
+		}
+	}
+}
+
+func TestBucketBurst(t *testing.T) {
+	b := NewBucket(Rate{Burst: 3, Per: time.Hour})
+	for i := 0; i < 3; i++ {
+		if ok, _ := b.Allow(); !ok {
+			t.Fatalf("event %d of the burst was refused", i+1)
+		}
+	}
+	ok, retry := b.Allow()
+	if ok {
+		t.Fatal("event after the burst was allowed")
+	}
+	// one token takes Per/Burst to refill
+	if retry <= 19*time.Minute || retry > 20*time.Minute {
+		t.Errorf("retry = %v, want about 20m", retry)
+	}
+}
+
+func TestBucketRefill(t *testing.T) {
+	tests := []struct {
+		// tokens are left in the bucket before the time passes
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, 0, 0},
+		{0, 5 * time.Second, 1},
+		{0, 10 * time.Second, 2},
+		{0.5, 2500 * time.Millisecond, 1},
+		// the bucket doesn't hold more than the burst
+		{1, time.Minute, 2},
+	}
+	for _, tt := range tests {
+		b := NewBucket(Rate{Burst: 2, Per: 10 * time.Second})
+		b.tokens = tt.tokens
+		b.refill(b.last.Add(tt.elapsed))
+		if b.tokens != tt.want {
+			t.Errorf("%v tokens after %v = %v, want %v", tt.tokens, tt.elapsed, b.tokens, tt.want)
+		}
+	}
+}
+
+func TestBucketRefund(t *testing.T) {
+	b := NewBucket(Rate{Burst: 1, Per: time.Hour})
+	b.Allow()
+	b.Refund()
+	if ok, _ := b.Allow(); !ok {
+		t.Error("the refunded token wasn't available")
+	}
+	// refunds don't grow the bucket beyond the burst
+	b.Refund()
+	b.Refund()
+	b.Allow()
+	if ok, _ := b.Allow(); ok {
+		t.Error("refunds added more tokens than the burst")
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	var nilBucket *Bucket
+	var nilLimiter *Limiter
+	for name, allow := range map[string]func() (bool, time.Duration){
+		"zero bucket":  NewBucket(Rate{}).Allow,
+		"nil bucket":   nilBucket.Allow,
+		"zero limiter": func() (bool, time.Duration) { return NewLimiter(Rate{}).Allow("key") },
+		"nil limiter":  func() (bool, time.Duration) { return nilLimiter.Allow("key") },
+	} {
+		for i := 0; i < 100; i++ {
+			if ok, _ := allow(); !ok {
+				t.Errorf("%s refused an event", name)
+				break
+			}
+		}
+	}
+	if err := nilBucket.Wait(context.Background()); err != nil {
+		t.Errorf("Wait on a nil bucket: %v", err)
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	b := NewBucket(Rate{Burst: 1, Per: 50 * time.Millisecond})
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the next token is there after 50ms
+	start := time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Wait returned after %v, want about 50ms", elapsed)
+	}
+}
+
+func TestBucketWaitCanceled(t *testing.T) {
+	b := NewBucket(Rate{Burst: 1, Per: time.Hour})
+	b.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wait returned after %v, not when the context was done", elapsed)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(Rate{Burst: 1, Per: time.Hour})
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("first event of a was refused")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("second event of a was allowed")
+	}
+	// every key has its own bucket
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("first event of b was refused")
+	}
+	l.Refund("a")
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("the refunded token of a wasn't available")
+	}
+	// unknown keys have nothing to refund
+	l.Refund("c")
+
+	// only full buckets are forgotten
+	l.buckets["b"].tokens = 1
+	l.Clean()
+	if _, ok := l.buckets["a"]; !ok {
+		t.Error("Clean forgot the empty bucket of a")
+	}
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("Clean kept the full bucket of b")
+	}
+}
@@ -0,0 +1,203 @@
+// Package settings stores per-guild configuration in a local JSON database.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AutolinkMode controls where symbol references are answered in a guild.
+type AutolinkMode string
+
+const (
+	// AutolinkOff disables auto-linking in the whole guild.
+	AutolinkOff AutolinkMode = "off"
+	// AutolinkChannels enables auto-linking only in channels that opted in.
+	AutolinkChannels AutolinkMode = "channels"
+	// AutolinkOn enables auto-linking in every channel.
+	AutolinkOn AutolinkMode = "on"
+)
+
+// Languages are the supported response languages; the first one is the default.
+var Languages = []string{"en"}
+
+// Guild is the configuration of a single guild.
+// The zero value is the default configuration.
+type Guild struct {
+	Prefix           string       `json:"prefix,omitempty"`
+	AllowedChannels  []string     `json:"allowedChannels,omitempty"`
+	LockedChannels   []string     `json:"lockedChannels,omitempty"`
+	DisabledCommands []string     `json:"disabledCommands,omitempty"`
+	Autolink         AutolinkMode `json:"autolink,omitempty"`
+	AutolinkChannels []string     `json:"autolinkChannels,omitempty"`
+	Language         string       `json:"language,omitempty"`
+}
+
+// ChannelAllowed reports whether commands may be used in the channel.
+func (g Guild) ChannelAllowed(channelID string) bool {
+	if contains(g.LockedChannels, channelID) {
+		return false
+	}
+	return len(g.AllowedChannels) == 0 || contains(g.AllowedChannels, channelID)
+}
+
+// CommandEnabled reports whether the command is enabled.
+func (g Guild) CommandEnabled(name string) bool {
+	return !contains(g.DisabledCommands, name)
+}
+
+// AutolinkMode returns the auto-link mode, defaulting to AutolinkChannels.
+func (g Guild) AutolinkMode() AutolinkMode {
+	if g.Autolink == "" {
+		return AutolinkChannels
+	}
+	return g.Autolink
+}
+
+// AutolinkEnabled reports whether symbol references should be answered in the channel.
+func (g Guild) AutolinkEnabled(channelID string) bool {
+	switch g.AutolinkMode() {
+	case AutolinkOn:
+		return true
+	case AutolinkChannels:
+		return contains(g.AutolinkChannels, channelID)
+	}
+	return false
+}
+
+// Lang returns the response language, defaulting to the first of Languages.
+func (g Guild) Lang() string {
+	if g.Language == "" {
+		return Languages[0]
+	}
+	return g.Language
+}
+
+// copy returns a deep copy of g.
+func (g Guild) copy() Guild {
+	g.AllowedChannels = append([]string(nil), g.AllowedChannels...)
+	g.LockedChannels = append([]string(nil), g.LockedChannels...)
+	g.DisabledCommands = append([]string(nil), g.DisabledCommands...)
+	g.AutolinkChannels = append([]string(nil), g.AutolinkChannels...)
+	return g
+}
+
+// Store is a JSON file backed collection of guild configurations.
+// It is safe for concurrent use.
+type Store struct {
+	mu     sync.RWMutex
+	path   string
+	guilds map[string]*Guild
+}
+
+// Open loads the store at path. A missing file results in an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		guilds: make(map[string]*Guild),
+	}
+	fileBytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fileBytes, &s.guilds); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Guild returns the configuration of a guild.
+// Guilds without stored settings, and a nil Store, return the default configuration.
+func (s *Store) Guild(guildID string) Guild {
+	if s == nil {
+		return Guild{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.guilds[guildID]; ok {
+		return g.copy()
+	}
+	return Guild{}
+}
+
+// Update calls fn with the configuration of a guild and saves the result.
+func (s *Store) Update(guildID string, fn func(g *Guild)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.guilds[guildID]
+	if !ok {
+		g = &Guild{}
+		s.guilds[guildID] = g
+	}
+	fn(g)
+	return s.save()
+}
+
+// Reset removes all stored settings of a guild.
+func (s *Store) Reset(guildID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.guilds, guildID)
+	return s.save()
+}
+
+// save writes the store to disk. The caller must hold s.mu.
+func (s *Store) save() error {
+	return WriteJSON(s.path, s.guilds)
+}
+
+// WriteJSON replaces the file at path with v encoded as indented JSON.
+// The file is written to a temporary file first, so a crash never leaves a partial file behind.
+func WriteJSON(path string, v interface{}) error {
+	fileBytes, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(fileBytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Add appends id to list unless it is already present.
+func Add(list []string, id string) []string {
+	if contains(list, id) {
+		return list
+	}
+	return append(list, id)
+}
+
+// Remove returns list without id.
+func Remove(list []string, id string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != id {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func contains(list []string, id string) bool {
+	for _, v := range list {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
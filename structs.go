@@ -1,25 +1,54 @@
 package main
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/post04/dr-docso/args"
+	"github.com/post04/dr-docso/bot"
+	"github.com/post04/dr-docso/ratelimit"
+	"github.com/post04/dr-docso/settings"
 )
 
 type Command struct {
+	// Name is the full name of the command, including the parent's for subcommands.
+	Name        string
+	Aliases     []string
+	Subcommands map[string]*Command
 	Help        string
 	Description string
-	Run         func(session *discordgo.Session, msg *discordgo.MessageCreate, prefix string)
+	// Permissions are the Discord permissions a member needs in the channel to run the command.
+	Permissions int64
+	// Roles restricts the command to members with at least one of these roles, given by ID or name.
+	Roles []string
+	// Args describes the arguments of the command, they are parsed before Run is called.
+	Args *args.Spec
+	// Timeout bounds a single run of the command, the handler's DefaultTimeout is used if it's zero.
+	Timeout time.Duration
+	Run     func(ctx context.Context, session bot.Session, msg *discordgo.MessageCreate, prefix string, a *args.Args)
+
+	userLimit    *ratelimit.Limiter
+	channelLimit *ratelimit.Limiter
 }
 
 type CommandHandler struct {
 	Prefix           string
+	Settings         *settings.Store
 	Commands         map[string]*Command
 	IgnoreBots       bool
-	OnMessageHandler func(session *discordgo.Session, msg *discordgo.MessageCreate)
-	Middleware       func(session *discordgo.Session, msg *discordgo.MessageCreate) bool
+	OnMessageHandler func(session bot.Session, msg *discordgo.MessageCreate)
+	Middleware       func(session bot.Session, msg *discordgo.MessageCreate) bool
 	HelpCommand      *discordgo.MessageEmbed
 	TimeStarted      time.Time
+	// Context is the parent of the contexts given to commands, cancelling it cancels every running command.
+	Context context.Context
+	// DefaultTimeout bounds the commands that don't set their own Timeout.
+	DefaultTimeout time.Duration
+
+	warnings *ratelimit.Limiter
+	running  sync.WaitGroup
 }
 
 type Config struct {
@@ -27,5 +56,38 @@ type Config struct {
 	Token          string   `json:"token"`
 	MainGuild      string   `json:"mainGuild"`
 	LockedChannels []string `json:"lockedChannels"`
+	QuietLock      bool     `json:"quietLock"`
 	SafeMode       bool     `json:"safeMode"`
+	Database       string   `json:"database"`
+	PagerDatabase  string   `json:"pagerDatabase"`
+	WatchDatabase  string   `json:"watchDatabase"`
+	// WatchInterval is how often watched modules are checked for new versions, e.g. "30m".
+	WatchInterval string `json:"watchInterval"`
+	// Cooldowns are the per-command rate limits, keyed by the full command name, e.g. "watch remove", or "default".
+	Cooldowns map[string]Cooldown `json:"cooldowns"`
+	// OutboundRate limits the requests made to pkg.go.dev.
+	OutboundRate ratelimit.Rate `json:"outboundRate"`
+	// ProxyRate limits the requests made to the module proxy, proxy.golang.org.
+	ProxyRate ratelimit.Rate `json:"proxyRate"`
+	// FeedRate limits the requests made to the vulnerability database and the Go release feed.
+	FeedRate ratelimit.Rate `json:"feedRate"`
+	// DocsBaseURL replaces https://pkg.go.dev/ for lookups, e.g. to use saved pages.
+	DocsBaseURL string `json:"docsBaseURL"`
+	// Announcements configures the Go release and security announcements.
+	Announcements AnnounceConfig `json:"announcements"`
+	// CommandTimeout and RequestTimeout are durations like "30s".
+	CommandTimeout string `json:"commandTimeout"`
+	RequestTimeout string `json:"requestTimeout"`
+}
+
+// AnnounceConfig configures where Go releases and vulnerability database entries are announced.
+type AnnounceConfig struct {
+	// Channels receive the announcements, none turns them off.
+	Channels []string `json:"channels"`
+	// ReleasesURL and VulnURL replace the Go release feed and vulnerability database, the latter is used by the vuln command too.
+	ReleasesURL string `json:"releasesURL"`
+	VulnURL     string `json:"vulnURL"`
+	// Interval is how often the feeds are checked, e.g. "1h".
+	Interval string `json:"interval"`
+	Database string `json:"database"`
 }
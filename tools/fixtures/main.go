@@ -0,0 +1,193 @@
+// Command fixtures saves pkg.go.dev pages and the docs parsed from them, and
+// reports how the parsed docs changed since the fixtures were last saved.
+//
+// Refresh the fixtures and show what changed:
+//
+//	go run ./tools/fixtures -update
+//
+// Serve the saved pages, e.g. for a bot configured with docsBaseURL "http://localhost:8080/":
+//
+//	go run ./tools/fixtures -serve :8080
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/post04/dr-docso/docs"
+)
+
+// defaultPackages cover the standard library, a module with generics and a large package with many methods.
+var defaultPackages = []string{
+	"strings",
+	"golang.org/x/exp/slices",
+	"net/http",
+}
+
+func main() {
+	var (
+		dir     = flag.String("dir", "docs/testdata", "directory of the fixtures")
+		base    = flag.String("base", docs.BASE, "where to fetch pages from")
+		update  = flag.Bool("update", false, "overwrite the fixtures with the fetched pages")
+		serve   = flag.String("serve", "", "serve the saved pages on this address instead")
+		timeout = flag.Duration("timeout", 30*time.Second, "timeout of a page request")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: fixtures [flags] [packages]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *serve != "" {
+		log.Fatal(http.ListenAndServe(*serve, servePages(*dir)))
+	}
+
+	pkgs := flag.Args()
+	if len(pkgs) == 0 {
+		pkgs = defaultPackages
+	}
+	client := &http.Client{Timeout: *timeout}
+	changed := false
+	for _, pkg := range pkgs {
+		diff, err := refresh(client, *dir, *base, pkg, *update)
+		if err != nil {
+			log.Fatalf("%s: %s", pkg, err)
+		}
+		if len(diff) == 0 {
+			fmt.Printf("%s: unchanged\n", pkg)
+			continue
+		}
+		changed = true
+		fmt.Printf("%s:\n", pkg)
+		for _, line := range diff {
+			fmt.Printf("\t%s\n", line)
+		}
+	}
+	if changed && !*update {
+		fmt.Println("run with -update to save the changes")
+		os.Exit(1)
+	}
+}
+
+// fixtureName returns the base name of the files of pkg.
+func fixtureName(pkg string) string {
+	return strings.ReplaceAll(pkg, "/", "_")
+}
+
+// refresh fetches the page of pkg, parses it and compares the result to the golden doc.
+// With update, the page and the golden doc are saved.
+func refresh(client *http.Client, dir, base, pkg string, update bool) ([]string, error) {
+	resp, err := client.Get(base + pkg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := docs.Parse(pkg, bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	doc.URL = docs.BASE + pkg
+
+	golden := filepath.Join(dir, fixtureName(pkg)+".golden.json")
+	var old *docs.Doc
+	if fileBytes, err := os.ReadFile(golden); err == nil {
+		old = new(docs.Doc)
+		if err := json.Unmarshal(fileBytes, old); err != nil {
+			return nil, fmt.Errorf("%s: %w", golden, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	diff := diffDocs(old, doc)
+
+	if update && len(diff) > 0 {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, fixtureName(pkg)+".html"), page, 0o644); err != nil {
+			return nil, err
+		}
+		fileBytes, err := json.MarshalIndent(doc, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(golden, append(fileBytes, '\n'), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+// diffDocs describes the structural differences between two docs:
+// added and removed symbols and changed signatures, comments and overviews.
+func diffDocs(old, doc *docs.Doc) []string {
+	if old == nil {
+		return []string{fmt.Sprintf("new fixture: %d functions, %d types", len(doc.Functions), len(doc.Types))}
+	}
+	var diff []string
+	if old.Overview != doc.Overview {
+		diff = append(diff, "overview changed")
+	}
+	api := docs.Diff(old, doc)
+	for _, c := range api.Added {
+		diff = append(diff, fmt.Sprintf("+ %s %s", c.Kind, c.Name))
+	}
+	for _, c := range api.Removed {
+		diff = append(diff, fmt.Sprintf("- %s %s", c.Kind, c.Name))
+	}
+	for _, c := range api.Changed {
+		diff = append(diff, fmt.Sprintf("~ %s %s: %q -> %q", c.Kind, c.Name, c.Old, c.New))
+	}
+
+	var changed []string
+	oldComments := comments(old)
+	for key, c := range comments(doc) {
+		if was, ok := oldComments[key]; ok && was != c {
+			changed = append(changed, fmt.Sprintf("~ %s: comments changed", key))
+		}
+	}
+	sort.Strings(changed)
+	return append(diff, changed...)
+}
+
+// comments returns the comments of every symbol, keyed by kind and name.
+func comments(doc *docs.Doc) map[string]string {
+	m := make(map[string]string)
+	for _, fn := range doc.Functions {
+		key := "func " + fn.Name
+		if fn.MethodOf != "" {
+			key = "method " + fn.MethodOf + "." + fn.Name
+		}
+		m[key] = strings.Join(fn.Comments, "\n")
+	}
+	for _, t := range doc.Types {
+		m["type "+t.Name] = strings.Join(t.Comments, "\n")
+	}
+	return m
+}
+
+// servePages serves the saved pages under the paths pkg.go.dev uses.
+func servePages(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkg := strings.TrimPrefix(r.URL.Path, "/")
+		http.ServeFile(w, r, filepath.Join(dir, fixtureName(pkg)+".html"))
+	})
+}
@@ -0,0 +1,153 @@
+// Package watch stores the modules channels are subscribed to in a local JSON database.
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/post04/dr-docso/settings"
+)
+
+// Subscription subscribes a channel to the releases of a module.
+type Subscription struct {
+	Module    string    `json:"module"`
+	GuildID   string    `json:"guildID,omitempty"`
+	ChannelID string    `json:"channelID"`
+	AddedBy   string    `json:"addedBy"`
+	Added     time.Time `json:"added"`
+}
+
+// Store is a JSON file backed collection of subscriptions, and the latest
+// version of every watched module that was announced.
+// It is safe for concurrent use.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data struct {
+		Subscriptions []Subscription    `json:"subscriptions"`
+		Latest        map[string]string `json:"latest"`
+	}
+}
+
+// Open loads the store at path. A missing file results in an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	s.data.Latest = make(map[string]string)
+	fileBytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fileBytes, &s.data); err != nil {
+		return nil, err
+	}
+	if s.data.Latest == nil {
+		s.data.Latest = make(map[string]string)
+	}
+	return s, nil
+}
+
+// Add subscribes a channel to a module and remembers latest as the version that was announced last.
+// It reports false if the channel was already subscribed.
+func (s *Store) Add(sub Subscription, latest string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.data.Subscriptions {
+		if existing.ChannelID == sub.ChannelID && existing.Module == sub.Module {
+			return false, nil
+		}
+	}
+	s.data.Subscriptions = append(s.data.Subscriptions, sub)
+	if _, ok := s.data.Latest[sub.Module]; !ok {
+		s.data.Latest[sub.Module] = latest
+	}
+	return true, s.save()
+}
+
+// Remove unsubscribes a channel from a module. It reports false if the channel wasn't subscribed.
+func (s *Store) Remove(channelID, module string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := false
+	subs := s.data.Subscriptions[:0]
+	for _, sub := range s.data.Subscriptions {
+		if sub.ChannelID == channelID && sub.Module == module {
+			removed = true
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	s.data.Subscriptions = subs
+	if !removed {
+		return false, nil
+	}
+	if len(s.subscribers(module)) == 0 {
+		delete(s.data.Latest, module)
+	}
+	return true, s.save()
+}
+
+// Channel returns the subscriptions of a channel, sorted by module.
+func (s *Store) Channel(channelID string) []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subs []Subscription
+	for _, sub := range s.data.Subscriptions {
+		if sub.ChannelID == channelID {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Module < subs[j].Module })
+	return subs
+}
+
+// Modules returns every watched module with the version that was announced last.
+func (s *Store) Modules() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	modules := make(map[string]string, len(s.data.Latest))
+	for module, latest := range s.data.Latest {
+		modules[module] = latest
+	}
+	return modules
+}
+
+// Subscribers returns the subscriptions to a module.
+func (s *Store) Subscribers(module string) []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribers(module)
+}
+
+func (s *Store) subscribers(module string) []Subscription {
+	var subs []Subscription
+	for _, sub := range s.data.Subscriptions {
+		if sub.Module == module {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// SetLatest remembers the version of a module that was announced last.
+func (s *Store) SetLatest(module, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Latest[module]; !ok {
+		// the last subscription was removed in the meantime
+		return nil
+	}
+	s.data.Latest[module] = version
+	return s.save()
+}
+
+// save writes the store to disk. The caller must hold s.mu.
+func (s *Store) save() error {
+	return settings.WriteJSON(s.path, &s.data)
+}